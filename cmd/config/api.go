@@ -1,12 +1,67 @@
 package config
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
-var (
-	// Api config
-	API_PORT string
+// Stores the API can keep its data in
+const (
+	STORE_POSTGRES = "postgres"
+	STORE_MEMORY   = "memory"
 )
 
-func LoadApiConfig() {
-	API_PORT = os.Getenv("API_CHI_PORT")
+type ApiConfig struct {
+	Port  string `yaml:"port" toml:"port"`
+	Store string `yaml:"store" toml:"store"`
+
+	// Time allowed for the queries of read and write routes, zero for no limit
+	ReadTimeout  time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+
+	// Apply pending migrations before serving
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+
+	// How often scheduled posts are checked for publishing, zero to never
+	PublishInterval time.Duration `yaml:"publish_interval" toml:"publish_interval"`
+}
+
+func defaultApiConfig() ApiConfig {
+	return ApiConfig{
+		Port:            "5003",
+		Store:           STORE_POSTGRES,
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    10 * time.Second,
+		PublishInterval: time.Minute,
+	}
+}
+
+func (c *ApiConfig) readEnv(env *envReader) {
+	env.string("API_CHI_PORT", &c.Port)
+	env.string("API_CHI_STORE", &c.Store)
+	env.duration("API_CHI_READ_TIMEOUT", &c.ReadTimeout)
+	env.duration("API_CHI_WRITE_TIMEOUT", &c.WriteTimeout)
+	env.bool("API_CHI_AUTO_MIGRATE", &c.AutoMigrate)
+	env.duration("API_CHI_PUBLISH_INTERVAL", &c.PublishInterval)
+}
+
+func (c *ApiConfig) validate() []error {
+	errs := []error{}
+	if c.Port == "" {
+		errs = append(errs, errors.New("api.port is required"))
+	}
+	if c.Store != STORE_POSTGRES && c.Store != STORE_MEMORY {
+		errs = append(errs, fmt.Errorf("api.store must be %q or %q, got %q", STORE_POSTGRES, STORE_MEMORY, c.Store))
+	}
+	if c.ReadTimeout < 0 {
+		errs = append(errs, errors.New("api.read_timeout must not be negative"))
+	}
+	if c.WriteTimeout < 0 {
+		errs = append(errs, errors.New("api.write_timeout must not be negative"))
+	}
+	if c.PublishInterval < 0 {
+		errs = append(errs, errors.New("api.publish_interval must not be negative"))
+	}
+	return errs
 }
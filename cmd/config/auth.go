@@ -1,18 +1,65 @@
 package config
 
-import "os"
-
-var (
-	// Auth config
-	AUTH_USERNAME    string
-	AUTH_PASSWORD    string
-	AUTH_SECRET_KEY  string
-	AUTH_BCRYPT_COST string
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
-func LoadAuthConfig() {
-	AUTH_USERNAME = os.Getenv("API_CHI_AUTH_USERNAME")
-	AUTH_PASSWORD = os.Getenv("API_CHI_AUTH_PASSWORD")
-	AUTH_SECRET_KEY = os.Getenv("API_CHI_AUTH_SECRET_KEY")
-	AUTH_BCRYPT_COST = os.Getenv("API_CHI_AUTH_BCRYPT_COST")
+type AuthConfig struct {
+	Username   string `yaml:"username" toml:"username"`
+	Password   string `yaml:"password" toml:"password"`
+	SecretKey  string `yaml:"secret_key" toml:"secret_key"`
+	BcryptCost int    `yaml:"bcrypt_cost" toml:"bcrypt_cost"`
+
+	// How long draft preview links stay valid
+	PreviewTtl time.Duration `yaml:"preview_ttl" toml:"preview_ttl"`
+}
+
+func defaultAuthConfig() AuthConfig {
+	return AuthConfig{
+		BcryptCost: bcrypt.DefaultCost,
+		PreviewTtl: 72 * time.Hour,
+	}
+}
+
+func (c *AuthConfig) readEnv(env *envReader) {
+	env.string("API_CHI_AUTH_USERNAME", &c.Username)
+	env.string("API_CHI_AUTH_PASSWORD", &c.Password)
+	env.string("API_CHI_AUTH_SECRET_KEY", &c.SecretKey)
+	env.int("API_CHI_AUTH_BCRYPT_COST", &c.BcryptCost)
+	env.duration("API_CHI_AUTH_PREVIEW_TTL", &c.PreviewTtl)
+}
+
+func (c *AuthConfig) validate() []error {
+	errs := []error{}
+	if c.Username == "" {
+		errs = append(errs, errors.New("auth.username is required"))
+	}
+	if c.Password == "" {
+		errs = append(errs, errors.New("auth.password is required"))
+	}
+	// An empty key would sign tokens anybody can forge
+	if c.SecretKey == "" {
+		errs = append(errs, errors.New("auth.secret_key is required"))
+	}
+	if c.BcryptCost < bcrypt.MinCost || c.BcryptCost > bcrypt.MaxCost {
+		errs = append(errs, fmt.Errorf("auth.bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+	if c.PreviewTtl <= 0 {
+		errs = append(errs, errors.New("auth.preview_ttl must be positive"))
+	}
+	return errs
+}
+
+func (c AuthConfig) redacted() AuthConfig {
+	if c.Password != "" {
+		c.Password = REDACTED
+	}
+	if c.SecretKey != "" {
+		c.SecretKey = REDACTED
+	}
+	return c
 }
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FILE_ENV names the environment variable holding the optional config file
+const FILE_ENV = "API_CHI_CONFIG"
+
+// REDACTED replaces secrets when the config is printed
+const REDACTED = "[REDACTED]"
+
+// Config is the whole application configuration. Values come from the
+// defaults, then the optional config file, then the environment, each
+// one overriding the previous.
+type Config struct {
+	Api      ApiConfig      `yaml:"api" toml:"api"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	Postgres PostgresConfig `yaml:"postgres" toml:"postgres"`
+	Web      WebConfig      `yaml:"web" toml:"web"`
+}
+
+// Default returns the config used when nothing else is set
+func Default() Config {
+	return Config{
+		Api:      defaultApiConfig(),
+		Auth:     defaultAuthConfig(),
+		Postgres: defaultPostgresConfig(),
+		Web:      defaultWebConfig(),
+	}
+}
+
+// Load reads the config from path and the environment and validates it,
+// path may be empty to only use the environment
+func Load(path string) (*Config, error) {
+	value, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := value.Validate(); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Read is Load without validation
+func Read(path string) (*Config, error) {
+	value := Default()
+	lookup := os.LookupEnv
+
+	// Apply config file
+	if path != "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			if err := readYaml(path, &value); err != nil {
+				return nil, err
+			}
+		case ".toml":
+			if err := readToml(path, &value); err != nil {
+				return nil, err
+			}
+		case ".env":
+			vars, err := readDotEnv(path)
+			if err != nil {
+				return nil, err
+			}
+			// Variables set in the environment win over the ones of the file
+			lookup = func(key string) (string, bool) {
+				if envValue := os.Getenv(key); envValue != "" {
+					return envValue, true
+				}
+				fileValue, ok := vars[key]
+				return fileValue, ok
+			}
+		default:
+			return nil, fmt.Errorf("config file %s: unsupported format, use .yaml, .toml or .env", path)
+		}
+	}
+
+	// Apply environment
+	env := envReader{lookup: lookup}
+	value.Api.readEnv(&env)
+	value.Auth.readEnv(&env)
+	value.Postgres.readEnv(&env)
+	value.Web.readEnv(&env)
+	if err := errors.Join(env.errs...); err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+// Validate checks every section and reports all problems at once
+func (c *Config) Validate() error {
+	errs := []error{}
+	errs = append(errs, c.Api.validate()...)
+	errs = append(errs, c.Auth.validate()...)
+	if c.Api.Store == STORE_POSTGRES {
+		errs = append(errs, c.Postgres.validate()...)
+	}
+	errs = append(errs, c.Web.validate()...)
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the config safe to print
+func (c *Config) Redacted() Config {
+	value := *c
+	value.Auth = c.Auth.redacted()
+	value.Postgres = c.Postgres.redacted()
+	return value
+}
+
+func readYaml(path string, value *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// readToml rejects unknown keys like readYaml does
+func readToml(path string, value *Config) error {
+	metadata, err := toml.DecodeFile(path, value)
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return fmt.Errorf("config file %s: unknown fields %s", path, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// readDotEnv parses KEY=VALUE lines, values may be quoted and may refer
+// to earlier variables or the environment with ${NAME}
+func readDotEnv(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	value := map[string]string{}
+	expand := func(name string) string {
+		if envValue, ok := value[name]; ok {
+			return envValue
+		}
+		return os.Getenv(name)
+	}
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line += 1
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		key, raw, found := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("config file %s:%d: expected KEY=VALUE", path, line)
+		}
+		raw = strings.TrimSpace(raw)
+
+		// Single quotes keep the value as is
+		if len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'' {
+			value[key] = raw[1 : len(raw)-1]
+			continue
+		}
+		if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+			raw = raw[1 : len(raw)-1]
+		}
+		value[key] = os.Expand(raw, expand)
+	}
+
+	return value, scanner.Err()
+}
+
+// envReader applies environment variables to config fields and collects
+// the values it can't parse
+type envReader struct {
+	lookup func(key string) (string, bool)
+	errs   []error
+}
+
+func (r *envReader) string(key string, target *string) {
+	if value, ok := r.lookup(key); ok && value != "" {
+		*target = value
+	}
+}
+
+func (r *envReader) int(key string, target *int) {
+	if value, ok := r.lookup(key); ok && value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s: invalid integer %q", key, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+func (r *envReader) bool(key string, target *bool) {
+	if value, ok := r.lookup(key); ok && value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s: invalid boolean %q", key, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+func (r *envReader) duration(key string, target *time.Duration) {
+	if value, ok := r.lookup(key); ok && value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s: invalid duration %q", key, value))
+			return
+		}
+		*target = parsed
+	}
+}
@@ -1,22 +1,181 @@
 package config
 
 import (
-	"context"
-	"os"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 )
 
-var CTX context.Context = context.Background()
+// SEARCH_LANGUAGE is the default text search configuration of Postgres
+const SEARCH_LANGUAGE = "english"
 
-var (
-	// Postgresql database config
-	POSTGRES_USERNAME string
-	POSTGRES_PASSWORD string
-	POSTGRES_HOST     string
-	POSTGRES_PORT     string
-	POSTGRES_DATABASE string
-	POSTGRES_URL      string
-)
+var searchLanguageRegexp = regexp.MustCompile(`^[a-z_]+$`)
+
+type PostgresConfig struct {
+	Url string `yaml:"url" toml:"url"`
+
+	// Text search configuration used to index and search posts, like
+	// english or simple
+	SearchLanguage string `yaml:"search_language" toml:"search_language"`
+
+	// Connection pool settings, zero keeps the pgxpool default
+	MaxConns        int           `yaml:"max_conns" toml:"max_conns"`
+	MinConns        int           `yaml:"min_conns" toml:"min_conns"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time"`
+}
+
+func defaultPostgresConfig() PostgresConfig {
+	return PostgresConfig{SearchLanguage: SEARCH_LANGUAGE}
+}
+
+func (c *PostgresConfig) readEnv(env *envReader) {
+	env.string("POSTGRES_URL", &c.Url)
+	env.string("POSTGRES_SEARCH_LANGUAGE", &c.SearchLanguage)
+	env.int("POSTGRES_MAX_CONNS", &c.MaxConns)
+	env.int("POSTGRES_MIN_CONNS", &c.MinConns)
+	env.duration("POSTGRES_MAX_CONN_LIFETIME", &c.MaxConnLifetime)
+	env.duration("POSTGRES_MAX_CONN_IDLE_TIME", &c.MaxConnIdleTime)
+}
+
+func (c *PostgresConfig) validate() []error {
+	errs := []error{}
+	if c.Url == "" {
+		errs = append(errs, errors.New("postgres.url is required"))
+	}
+	if !searchLanguageRegexp.MatchString(c.SearchLanguage) {
+		errs = append(errs, fmt.Errorf("postgres.search_language must be a text search configuration name, got %q", c.SearchLanguage))
+	}
+	if c.MaxConns < 0 || c.MinConns < 0 {
+		errs = append(errs, errors.New("postgres.max_conns and postgres.min_conns must not be negative"))
+	}
+	if c.MaxConns > 0 && c.MinConns > c.MaxConns {
+		errs = append(errs, errors.New("postgres.min_conns must not be greater than postgres.max_conns"))
+	}
+	if c.MaxConnLifetime < 0 || c.MaxConnIdleTime < 0 {
+		errs = append(errs, errors.New("postgres.max_conn_lifetime and postgres.max_conn_idle_time must not be negative"))
+	}
+	return errs
+}
+
+// Validate checks the postgres section alone, for commands that only
+// need the database
+func (c *PostgresConfig) Validate() error {
+	return errors.Join(c.validate()...)
+}
+
+// secretParams are the connection parameters holding a password
+var secretParams = map[string]bool{"password": true, "sslpassword": true}
+
+func (c PostgresConfig) redacted() PostgresConfig {
+	if c.Url == "" {
+		return c
+	}
+
+	// Keep the host and database visible, only hide the passwords. Urls
+	// and key/value connection strings are both accepted by pgx, anything
+	// else is hidden whole.
+	switch {
+	case strings.Contains(c.Url, "://"):
+		c.Url = redactedUrl(c.Url)
+	case strings.Contains(c.Url, "="):
+		c.Url = redactedKeyValues(c.Url)
+	default:
+		c.Url = REDACTED
+	}
+	return c
+}
+
+// redactedUrl hides the password of the user info and of the query
+func redactedUrl(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return REDACTED
+	}
+
+	query, err := url.ParseQuery(parsed.RawQuery)
+	if err != nil {
+		return REDACTED
+	}
+	for key := range query {
+		if secretParams[strings.ToLower(key)] {
+			query.Set(key, "xxxxx")
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.Redacted()
+}
+
+// redactedKeyValues hides the password of a key/value connection string
+// like "host=db password=secret", values may be quoted with ' and escape
+// characters with \
+func redactedKeyValues(value string) string {
+	redacted := strings.Builder{}
+	i := 0
+	for {
+		// Key up to =, spaces are allowed around it
+		start := i
+		for i < len(value) && isSpace(value[i]) {
+			i++
+		}
+		if i == len(value) {
+			redacted.WriteString(value[start:])
+			return redacted.String()
+		}
+		keyStart := i
+		for i < len(value) && value[i] != '=' && !isSpace(value[i]) {
+			i++
+		}
+		key := value[keyStart:i]
+		for i < len(value) && isSpace(value[i]) {
+			i++
+		}
+		if key == "" || i == len(value) || value[i] != '=' {
+			return REDACTED
+		}
+		i++
+		for i < len(value) && isSpace(value[i]) {
+			i++
+		}
+		redacted.WriteString(value[start:i])
+
+		// Value up to a space, or up to the closing quote
+		valueStart := i
+		quoted := i < len(value) && value[i] == '\''
+		if quoted {
+			i++
+		}
+		closed := !quoted
+		for i < len(value) {
+			if value[i] == '\\' {
+				i += 2
+				continue
+			}
+			if quoted && value[i] == '\'' {
+				i++
+				closed = true
+				break
+			}
+			if !quoted && isSpace(value[i]) {
+				break
+			}
+			i++
+		}
+		if !closed || i > len(value) {
+			return REDACTED
+		}
+
+		if secretParams[strings.ToLower(key)] {
+			redacted.WriteString(REDACTED)
+		} else {
+			redacted.WriteString(value[valueStart:i])
+		}
+	}
+}
 
-func LoadDatabaseConfig() {
-	POSTGRES_URL = os.Getenv("POSTGRES_URL")
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
 }
@@ -1,11 +1,26 @@
 package config
 
-import "os"
+import "errors"
 
-var (
-	WEB_URL string
-)
+type WebConfig struct {
+	// Origin of the web app allowed by CORS
+	Url string `yaml:"url" toml:"url"`
+}
+
+func defaultWebConfig() WebConfig {
+	return WebConfig{
+		Url: "http://localhost:5173",
+	}
+}
+
+func (c *WebConfig) readEnv(env *envReader) {
+	env.string("WEB_URL", &c.Url)
+}
 
-func LoadWebConfig() {
-	WEB_URL = os.Getenv("WEB_URL")
+func (c *WebConfig) validate() []error {
+	errs := []error{}
+	if c.Url == "" {
+		errs = append(errs, errors.New("web.url is required"))
+	}
+	return errs
 }
@@ -12,7 +12,11 @@ import (
 )
 
 type AuthController struct {
-	service services.AuthService
+	service *services.AuthService
+}
+
+func NewAuthController(service *services.AuthService) *AuthController {
+	return &AuthController{service: service}
 }
 
 func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
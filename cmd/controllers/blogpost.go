@@ -1,47 +1,194 @@
 package controllers
 
 import (
+	"api-chi/cmd/middlewares"
 	"api-chi/cmd/models"
 	"api-chi/cmd/services"
 	"api-chi/internal/convert"
+	"api-chi/internal/markdown"
 	"api-chi/internal/message"
+	"api-chi/internal/validate"
 
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 )
 
 type BlogPostController struct {
-	service services.BlogPostService
+	service  services.PostRepository
+	auth     *services.AuthService
+	markdown *markdown.Renderer
 }
 
-func (c *BlogPostController) Count(w http.ResponseWriter, r *http.Request) {
-	// Retrieve query parameters
-	search := r.URL.Query().Get("search")
-	tagsString := r.URL.Query().Get("tags")
+func NewBlogPostController(service services.PostRepository, auth *services.AuthService, renderer *markdown.Renderer) *BlogPostController {
+	return &BlogPostController{service: service, auth: auth, markdown: renderer}
+}
 
-	// Turn string tags query to array
-	tags := convert.StringToBlogtagSlice(tagsString)
+// visibleStatus returns the status of the posts the caller may see. Guests
+// only see published posts, logged in callers see every post unless they
+// ask for one status. It renders the error and returns false otherwise.
+func visibleStatus(w http.ResponseWriter, r *http.Request) (string, bool) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.STATUS_PUBLISHED, models.STATUS_DRAFT, models.STATUS_SCHEDULED, models.STATUS_ALL:
+	default:
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.INVALID_INPUT,
+			Data:    nil,
+		})
+		return "", false
+	}
 
-	// Open and close database after end
-	err := c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
+	if !middlewares.IsLoggedIn(r.Context()) {
+		if status != "" && status != models.STATUS_PUBLISHED {
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, message.Response{
+				Message: message.AUTH_FAILED,
+				Data:    nil,
+			})
+			return "", false
+		}
+		return models.STATUS_PUBLISHED, true
 	}
 
-	// Count data and return if failed or success
-	data, err := c.service.Count(search, tags)
+	if status == "" {
+		return models.STATUS_ALL, true
+	}
+	return status, true
+}
+
+// readFilter reads the filter of the count and list queries
+func readFilter(w http.ResponseWriter, r *http.Request) (models.BlogPostFilter, bool) {
+	status, ok := visibleStatus(w, r)
+	if !ok {
+		return models.BlogPostFilter{}, false
+	}
+	sort, ok := readSort(w, r, models.POST_SORTS)
+	if !ok {
+		return models.BlogPostFilter{}, false
+	}
+
+	tagMode := r.URL.Query().Get("tag_mode")
+	switch tagMode {
+	case "", models.TAG_MODE_ALL, models.TAG_MODE_ANY:
+	default:
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.INVALID_INPUT,
+			Data:    nil,
+		})
+		return models.BlogPostFilter{}, false
+	}
+
+	from, fromErr := readDate(r.URL.Query().Get("from"), false)
+	to, toErr := readDate(r.URL.Query().Get("to"), true)
+	if fromErr != nil || toErr != nil || (from != nil && to != nil && !from.Before(*to)) {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.INVALID_INPUT,
+			Data:    nil,
+		})
+		return models.BlogPostFilter{}, false
+	}
+
+	return models.BlogPostFilter{
+		Search:      strings.TrimSpace(r.URL.Query().Get("search")),
+		Tags:        convert.StringToBlogtagSlice(r.URL.Query().Get("tags")),
+		TagMode:     tagMode,
+		ExcludeTags: convert.StringToBlogtagSlice(r.URL.Query().Get("exclude_tags")),
+		Status:      status,
+		Sort:        sort,
+		From:        from,
+		To:          to,
+	}, true
+}
+
+// readDate reads a bound of a date range, nil when empty. It is either an
+// RFC 3339 time or a date in UTC, a date ending the range includes its
+// whole day.
+func readDate(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		if end {
+			date = date.AddDate(0, 0, 1)
+		}
+		return &date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		return nil, err
+	}
+	return &date, nil
+}
+
+// readFormat returns the format a single post is asked for in. It renders
+// the error and returns false when the format is unknown.
+func readFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", models.FORMAT_MARKDOWN:
+		return models.FORMAT_MARKDOWN, true
+	case models.FORMAT_HTML:
+		return format, true
+	default:
+		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, message.Response{
-			Message: message.GET_DATA_FAILED,
+			Message: message.INVALID_INPUT,
 			Data:    nil,
 		})
+		return "", false
+	}
+}
+
+// renderPost writes a single post, with its content rendered to HTML when
+// format asks for it
+func (c *BlogPostController) renderPost(w http.ResponseWriter, r *http.Request, data models.BlogPostContentWithTags, format string) {
+	if format != models.FORMAT_HTML {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, message.Response{
+			Message: message.GET_DATA_SUCCESS,
+			Data:    data,
+		})
+		return
+	}
+
+	document, err := c.markdown.Render(data.Content)
+	if err != nil {
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.GET_DATA_SUCCESS,
+		Data: models.BlogPostHtml{
+			BlogPostContentWithTags: data,
+			Html:                    document.Html,
+			Toc:                     document.Toc,
+		},
+	})
+}
+
+func (c *BlogPostController) Count(w http.ResponseWriter, r *http.Request) {
+	// Retrieve query parameters
+	filter, ok := readFilter(w, r)
+	if !ok {
+		return
+	}
+
+	// Count data and return if failed or success
+	data, err := c.service.Count(r.Context(), filter)
+	if err != nil {
+		renderError(w, r, err, message.GET_DATA_FAILED)
 		return
 	}
 
@@ -54,8 +201,10 @@ func (c *BlogPostController) Count(w http.ResponseWriter, r *http.Request) {
 
 func (c *BlogPostController) GetAll(w http.ResponseWriter, r *http.Request) {
 	// Retrieve query parameters
-	search := r.URL.Query().Get("search")
-	tagsString := r.URL.Query().Get("tags")
+	filter, ok := readFilter(w, r)
+	if !ok {
+		return
+	}
 	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
@@ -65,6 +214,12 @@ func (c *BlogPostController) GetAll(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+
+	// Without a page number the posts are read with cursors
+	if !r.URL.Query().Has("page") {
+		c.getAllWithCursor(w, r, filter, limit)
+		return
+	}
 	page, err := strconv.Atoi(r.URL.Query().Get("page"))
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
@@ -75,24 +230,62 @@ func (c *BlogPostController) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Turn string tags query to array
-	tags := convert.StringToBlogtagSlice(tagsString)
+	// Get all data and return if failed or success
+	data, meta, err := c.service.GetAll(r.Context(), filter, limit, page)
+	if err != nil {
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.GET_DATA_SUCCESS,
+		Data:    data,
+		Meta:    meta,
+	})
+}
+
+func (c *BlogPostController) getAllWithCursor(w http.ResponseWriter, r *http.Request, filter models.BlogPostFilter, limit int) {
+	cursor, ok := readCursor(w, r)
+	if !ok {
+		return
+	}
 
-	// Open and close database after end
-	err = c.service.Open()
-	defer c.service.Close()
+	// Get the page after the cursor and return if failed or success
+	data, cursors, err := c.service.GetAllWithCursor(r.Context(), filter, cursor, limit)
 	if err != nil {
-		log.Fatal(err)
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
 	}
+	renderCursorPage(w, r, data, cursors)
+}
 
-	// Get all data and return if failed or success
-	data, err := c.service.GetAll(search, tags, limit, page)
+// GetArchive returns the visible posts counted by year and month, with
+// their titles and slugs when posts is true
+func (c *BlogPostController) GetArchive(w http.ResponseWriter, r *http.Request) {
+	// Retrieve query parameters
+	filter, ok := readFilter(w, r)
+	if !ok {
+		return
+	}
+	withPosts := false
+	if value := r.URL.Query().Get("posts"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, message.Response{
+				Message: message.INVALID_INPUT,
+				Data:    nil,
+			})
+			return
+		}
+		withPosts = parsed
+	}
+
+	// Get the archive and return if failed or success
+	data, err := c.service.GetArchive(r.Context(), filter, withPosts)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, message.Response{
-			Message: message.GET_DATA_FAILED,
-			Data:    nil,
-		})
+		renderError(w, r, err, message.GET_DATA_FAILED)
 		return
 	}
 
@@ -105,8 +298,10 @@ func (c *BlogPostController) GetAll(w http.ResponseWriter, r *http.Request) {
 
 func (c *BlogPostController) GetAllWithContent(w http.ResponseWriter, r *http.Request) {
 	// Retrieve query parameters
-	search := r.URL.Query().Get("search")
-	tagsString := r.URL.Query().Get("tags")
+	filter, ok := readFilter(w, r)
+	if !ok {
+		return
+	}
 	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
@@ -126,24 +321,10 @@ func (c *BlogPostController) GetAllWithContent(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Turn string tags query to array
-	tags := convert.StringToBlogtagSlice(tagsString)
-
-	// Open and close database after end
-	err = c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	// Get all data and return if failed or success
-	data, err := c.service.GetAllWithContent(search, tags, limit, page)
+	data, meta, err := c.service.GetAllWithContent(r.Context(), filter, limit, page)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, message.Response{
-			Message: message.GET_DATA_FAILED,
-			Data:    nil,
-		})
+		renderError(w, r, err, message.GET_DATA_FAILED)
 		return
 	}
 
@@ -151,6 +332,7 @@ func (c *BlogPostController) GetAllWithContent(w http.ResponseWriter, r *http.Re
 	render.JSON(w, r, message.Response{
 		Message: message.GET_DATA_SUCCESS,
 		Data:    data,
+		Meta:    meta,
 	})
 }
 
@@ -165,24 +347,76 @@ func (c *BlogPostController) GetWithSlug(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Open and close database after end
-	err := c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
+	format, ok := readFormat(w, r)
+	if !ok {
+		return
+	}
+
+	// Drafts are only found by logged in callers
+	status := models.STATUS_PUBLISHED
+	if middlewares.IsLoggedIn(r.Context()) {
+		status = models.STATUS_ALL
 	}
 
 	// Get data and return if failed or success
-	data, err := c.service.GetWithSlug(slug)
+	data, err := c.service.GetWithSlug(r.Context(), slug, status)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
+		// Point old links at the current slug
+		moved := &services.MovedError{}
+		if errors.As(err, &moved) {
+			w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, slug)+moved.Slug)
+		}
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
+	}
+
+	c.renderPost(w, r, data, format)
+}
+
+func (c *BlogPostController) GetRelated(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	if slug == "" {
+		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, message.Response{
-			Message: message.GET_DATA_FAILED,
+			Message: message.INVALID_INPUT,
 			Data:    nil,
 		})
 		return
 	}
 
+	// Limit is optional, the service picks a default
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, message.Response{
+				Message: message.INVALID_INPUT,
+				Data:    nil,
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	// Drafts are only found by logged in callers, related posts are always published
+	status := models.STATUS_PUBLISHED
+	if middlewares.IsLoggedIn(r.Context()) {
+		status = models.STATUS_ALL
+	}
+
+	// Get data and return if failed or success
+	data, err := c.service.GetRelated(r.Context(), slug, status, limit)
+	if err != nil {
+		// Point old links at the current slug
+		moved := &services.MovedError{}
+		if errors.As(err, &moved) {
+			w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, slug+"/related")+moved.Slug+"/related")
+		}
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
+	}
+
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, message.Response{
 		Message: message.GET_DATA_SUCCESS,
@@ -190,6 +424,77 @@ func (c *BlogPostController) GetWithSlug(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+func (c *BlogPostController) CreatePreview(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.INVALID_INPUT,
+			Data:    nil,
+		})
+		return
+	}
+
+	// Only sign links to posts that exist and aren't published yet, drafts
+	// and scheduled posts alike
+	post, err := c.service.GetWithId(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err, message.CREATE_DATA_FAILED)
+		return
+	}
+	if !post.IsDraft {
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, message.Response{
+			Message: message.ALREADY_PUBLISHED,
+			Data:    nil,
+		})
+		return
+	}
+
+	// Sign preview token and return if failed or success
+	token, expiresAt, err := c.auth.GeneratePreviewToken(id)
+	if err != nil {
+		renderError(w, r, err, message.CREATE_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.CREATE_DATA_SUCCESS,
+		Data: models.BlogPostPreview{
+			Token:     token,
+			ExpiresAt: expiresAt,
+		},
+	})
+}
+
+func (c *BlogPostController) GetPreview(w http.ResponseWriter, r *http.Request) {
+	// The token alone grants access, whatever the post status
+	id, err := c.auth.ValidatePreviewToken(chi.URLParam(r, "token"))
+	if err != nil {
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, message.Response{
+			Message: message.AUTH_FAILED,
+			Data:    nil,
+		})
+		return
+	}
+
+	format, ok := readFormat(w, r)
+	if !ok {
+		return
+	}
+
+	// Get data and return if failed or success
+	data, err := c.service.GetWithId(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
+	}
+
+	c.renderPost(w, r, data, format)
+}
+
 func (c *BlogPostController) Create(w http.ResponseWriter, r *http.Request) {
 	// Get JSON from user input
 	input := models.BlogPostCreated{}
@@ -202,21 +507,16 @@ func (c *BlogPostController) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Open and close database after end
-	err := c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
+	// Check fields before touching the database
+	if err := validate.BlogPostCreated(&input); err != nil {
+		renderError(w, r, err, message.CREATE_DATA_FAILED)
+		return
 	}
 
 	// Create data and return if failed or success
-	data, err := c.service.Create(&input)
+	data, err := c.service.Create(r.Context(), &input)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, message.Response{
-			Message: message.CREATE_DATA_FAILED,
-			Data:    nil,
-		})
+		renderError(w, r, err, message.CREATE_DATA_FAILED)
 		return
 	}
 
@@ -239,21 +539,16 @@ func (c *BlogPostController) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Open and close database after end
-	err := c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
+	// Check fields before touching the database
+	if err := validate.BlogPostUpdated(&input); err != nil {
+		renderError(w, r, err, message.UPDATE_DATA_FAILED)
+		return
 	}
 
 	// Update data and return if failed or success
-	data, err := c.service.Update(&input)
+	data, err := c.service.Update(r.Context(), &input)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, message.Response{
-			Message: message.UPDATE_DATA_FAILED,
-			Data:    nil,
-		})
+		renderError(w, r, err, message.UPDATE_DATA_FAILED)
 		return
 	}
 
@@ -275,21 +570,10 @@ func (c *BlogPostController) Remove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Open and close database after end
-	err := c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	// Remove data and return if failed or success
-	data, err := c.service.Remove(id)
+	data, err := c.service.Remove(r.Context(), id)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, message.Response{
-			Message: message.REMOVE_DATA_FAILED,
-			Data:    nil,
-		})
+		renderError(w, r, err, message.REMOVE_DATA_FAILED)
 		return
 	}
 
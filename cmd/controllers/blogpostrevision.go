@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"api-chi/cmd/models"
+	"api-chi/cmd/services"
+	"api-chi/internal/diff"
+	"api-chi/internal/message"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type BlogPostRevisionController struct {
+	service services.RevisionRepository
+	posts   services.PostRepository
+}
+
+func NewBlogPostRevisionController(service services.RevisionRepository, posts services.PostRepository) *BlogPostRevisionController {
+	return &BlogPostRevisionController{service: service, posts: posts}
+}
+
+func (c *BlogPostRevisionController) GetAll(w http.ResponseWriter, r *http.Request) {
+	// Get all data and return if failed or success
+	data, err := c.service.GetAll(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.GET_DATA_SUCCESS,
+		Data:    data,
+	})
+}
+
+func (c *BlogPostRevisionController) Get(w http.ResponseWriter, r *http.Request) {
+	// Get data and return if failed or success
+	data, err := c.service.Get(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "revisionId"))
+	if err != nil {
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.GET_DATA_SUCCESS,
+		Data:    data,
+	})
+}
+
+func (c *BlogPostRevisionController) Diff(w http.ResponseWriter, r *http.Request) {
+	// Retrieve query parameters, without to the current post is compared
+	id := chi.URLParam(r, "id")
+	fromId := r.URL.Query().Get("from")
+	toId := r.URL.Query().Get("to")
+	if fromId == "" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.INVALID_INPUT,
+			Data:    nil,
+		})
+		return
+	}
+
+	from, err := c.service.Get(r.Context(), id, fromId)
+	if err != nil {
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
+	}
+	toTitle, toContent := "", ""
+	if toId != "" {
+		to, err := c.service.Get(r.Context(), id, toId)
+		if err != nil {
+			renderError(w, r, err, message.GET_DATA_FAILED)
+			return
+		}
+		toTitle, toContent = to.Title, to.Content
+	} else {
+		post, err := c.posts.GetWithId(r.Context(), id)
+		if err != nil {
+			renderError(w, r, err, message.GET_DATA_FAILED)
+			return
+		}
+		toTitle, toContent = post.Title, post.Content
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.GET_DATA_SUCCESS,
+		Data: models.BlogPostRevisionDiff{
+			From:    fromId,
+			To:      toId,
+			Title:   diff.Lines(from.Title, toTitle),
+			Content: diff.Lines(from.Content, toContent),
+		},
+	})
+}
+
+func (c *BlogPostRevisionController) Restore(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	// Get the revision and the post it goes back into
+	revision, err := c.service.Get(r.Context(), id, chi.URLParam(r, "revisionId"))
+	if err != nil {
+		renderError(w, r, err, message.UPDATE_DATA_FAILED)
+		return
+	}
+	post, err := c.posts.GetWithId(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err, message.UPDATE_DATA_FAILED)
+		return
+	}
+
+	// Custom slugs are kept, slugs made from the title follow the restored one
+	slug := post.Slug
+	if services.IsTitleSlug(post.Slug, post.Title) {
+		slug = ""
+	}
+
+	// Restoring is an update, so the replaced content becomes a revision too
+	input := models.BlogPostUpdated{
+		Id:        post.Id,
+		Title:     revision.Title,
+		Slug:      slug,
+		Summary:   post.Summary,
+		Content:   revision.Content,
+		CreatedAt: post.CreatedAt,
+		UpdatedAt: time.Now(),
+		IsDraft:   post.IsDraft,
+		PublishAt: post.PublishAt,
+		Tags:      post.Tags,
+	}
+	data, err := c.posts.Update(r.Context(), &input)
+	if err != nil {
+		renderError(w, r, err, message.UPDATE_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.UPDATE_DATA_SUCCESS,
+		Data:    data,
+	})
+}
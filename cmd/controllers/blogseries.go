@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"api-chi/cmd/middlewares"
+	"api-chi/cmd/models"
+	"api-chi/cmd/services"
+	"api-chi/internal/message"
+	"api-chi/internal/validate"
+
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type BlogSeriesController struct {
+	service services.SeriesRepository
+}
+
+func NewBlogSeriesController(service services.SeriesRepository) *BlogSeriesController {
+	return &BlogSeriesController{service: service}
+}
+
+func (c *BlogSeriesController) GetAll(w http.ResponseWriter, r *http.Request) {
+	// Get all data and return if failed or success
+	data, err := c.service.GetAll(r.Context())
+	if err != nil {
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.GET_DATA_SUCCESS,
+		Data:    data,
+	})
+}
+
+func (c *BlogSeriesController) GetWithSlug(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	if slug == "" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.INVALID_INPUT,
+			Data:    nil,
+		})
+		return
+	}
+
+	// Draft parts are only listed for logged in callers
+	status := models.STATUS_PUBLISHED
+	if middlewares.IsLoggedIn(r.Context()) {
+		status = models.STATUS_ALL
+	}
+
+	// Get data and return if failed or success
+	data, err := c.service.GetWithSlug(r.Context(), slug, status)
+	if err != nil {
+		renderError(w, r, err, message.GET_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.GET_DATA_SUCCESS,
+		Data:    data,
+	})
+}
+
+func (c *BlogSeriesController) Create(w http.ResponseWriter, r *http.Request) {
+	// Get JSON from user input
+	input := models.BlogSeriesCreated{}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.CREATE_DATA_FAILED,
+			Data:    nil,
+		})
+		return
+	}
+
+	// Check fields before touching the database
+	if err := validate.BlogSeriesCreated(&input); err != nil {
+		renderError(w, r, err, message.CREATE_DATA_FAILED)
+		return
+	}
+
+	// Create data and return if failed or success
+	data, err := c.service.Create(r.Context(), &input)
+	if err != nil {
+		renderError(w, r, err, message.CREATE_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.CREATE_DATA_SUCCESS,
+		Data:    data,
+	})
+}
+
+func (c *BlogSeriesController) Update(w http.ResponseWriter, r *http.Request) {
+	// Get JSON from user input
+	input := models.BlogSeriesUpdated{}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.UPDATE_DATA_FAILED,
+			Data:    nil,
+		})
+		return
+	}
+
+	// Check fields before touching the database
+	if err := validate.BlogSeriesUpdated(&input); err != nil {
+		renderError(w, r, err, message.UPDATE_DATA_FAILED)
+		return
+	}
+
+	// Update data and return if failed or success
+	data, err := c.service.Update(r.Context(), &input)
+	if err != nil {
+		renderError(w, r, err, message.UPDATE_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.UPDATE_DATA_SUCCESS,
+		Data:    data,
+	})
+}
+
+func (c *BlogSeriesController) Remove(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.REMOVE_DATA_FAILED,
+			Data:    nil,
+		})
+		return
+	}
+
+	// Remove data and return if failed or success
+	data, err := c.service.Remove(r.Context(), id)
+	if err != nil {
+		renderError(w, r, err, message.REMOVE_DATA_FAILED)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.REMOVE_DATA_SUCCESS,
+		Data:    data,
+	})
+}
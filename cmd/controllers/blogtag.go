@@ -4,9 +4,9 @@ import (
 	"api-chi/cmd/models"
 	"api-chi/cmd/services"
 	"api-chi/internal/message"
+	"api-chi/internal/validate"
 
 	"encoding/json"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -15,28 +15,21 @@ import (
 )
 
 type BlogTagController struct {
-	service services.BlogTagService
+	service services.TagRepository
+}
+
+func NewBlogTagController(service services.TagRepository) *BlogTagController {
+	return &BlogTagController{service: service}
 }
 
 func (c *BlogTagController) Count(w http.ResponseWriter, r *http.Request) {
 	// Retrieve query parameters
 	search := r.URL.Query().Get("search")
 
-	// Open and close database after end
-	err := c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	// Execute Count and return if failed or success
-	data, err := c.service.Count(search)
+	data, err := c.service.Count(r.Context(), search)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, message.Response{
-			Message: message.GET_DATA_FAILED,
-			Data:    nil,
-		})
+		renderError(w, r, err, message.GET_DATA_FAILED)
 		return
 	}
 
@@ -51,14 +44,34 @@ func (c *BlogTagController) GetAll(w http.ResponseWriter, r *http.Request) {
 	// Retrieve query parameters
 	search := r.URL.Query().Get("search")
 	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-	if err != nil {
+	if err != nil || limit < 1 {
 		render.Status(r, http.StatusBadRequest)
 		render.JSON(w, r, message.Response{
-			Message: message.GET_DATA_FAILED,
+			Message: message.INVALID_INPUT,
 			Data:    nil,
 		})
 		return
 	}
+	sort, ok := readSort(w, r, models.TAG_SORTS)
+	if !ok {
+		return
+	}
+
+	// Without a page number the tags are read with cursors
+	if !r.URL.Query().Has("page") {
+		cursor, ok := readCursor(w, r)
+		if !ok {
+			return
+		}
+
+		data, cursors, err := c.service.GetAllWithCursor(r.Context(), search, sort, cursor, limit)
+		if err != nil {
+			renderError(w, r, err, message.GET_DATA_FAILED)
+			return
+		}
+		renderCursorPage(w, r, data, cursors)
+		return
+	}
 	page, err := strconv.Atoi(r.URL.Query().Get("page"))
 	if err != nil {
 		render.Status(r, http.StatusBadRequest)
@@ -69,21 +82,10 @@ func (c *BlogTagController) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Open and close database after end
-	err = c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	// Execute Count and return if failed or success
-	data, err := c.service.GetAll(search, limit, page)
+	data, meta, err := c.service.GetAll(r.Context(), search, sort, limit, page)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, message.Response{
-			Message: message.GET_DATA_FAILED,
-			Data:    nil,
-		})
+		renderError(w, r, err, message.GET_DATA_FAILED)
 		return
 	}
 
@@ -91,6 +93,7 @@ func (c *BlogTagController) GetAll(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, message.Response{
 		Message: message.GET_DATA_SUCCESS,
 		Data:    data,
+		Meta:    meta,
 	})
 }
 
@@ -106,21 +109,16 @@ func (c *BlogTagController) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Open and close database after end
-	err := c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
+	// Check fields before touching the database
+	if err := validate.BlogTagCreated(&input); err != nil {
+		renderError(w, r, err, message.CREATE_DATA_FAILED)
+		return
 	}
 
 	// Execute Count and return if failed or success
-	data, err := c.service.Create(&input)
+	data, err := c.service.Create(r.Context(), &input)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, message.Response{
-			Message: message.CREATE_DATA_FAILED,
-			Data:    nil,
-		})
+		renderError(w, r, err, message.CREATE_DATA_FAILED)
 		return
 	}
 
@@ -143,21 +141,16 @@ func (c *BlogTagController) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Open and close database after end
-	err := c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
+	// Check fields before touching the database
+	if err := validate.BlogTagUpdated(&input); err != nil {
+		renderError(w, r, err, message.UPDATE_DATA_FAILED)
+		return
 	}
 
 	// Execute Count and return if failed or success
-	data, err := c.service.Update(&input)
+	data, err := c.service.Update(r.Context(), &input)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, message.Response{
-			Message: message.UPDATE_DATA_FAILED,
-			Data:    nil,
-		})
+		renderError(w, r, err, message.UPDATE_DATA_FAILED)
 		return
 	}
 
@@ -179,21 +172,10 @@ func (c *BlogTagController) Remove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Open and close database after end
-	err := c.service.Open()
-	defer c.service.Close()
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	// Execute Count and return if failed or success
-	data, err := c.service.Remove(id)
+	data, err := c.service.Remove(r.Context(), id)
 	if err != nil {
-		render.Status(r, http.StatusInternalServerError)
-		render.JSON(w, r, message.Response{
-			Message: message.REMOVE_DATA_FAILED,
-			Data:    nil,
-		})
+		renderError(w, r, err, message.REMOVE_DATA_FAILED)
 		return
 	}
 
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"api-chi/cmd/models"
+	"api-chi/internal/message"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// readCursor reads the cursor parameter of a listing, nil when there is
+// none. It renders the error and returns false when the cursor is malformed.
+func readCursor(w http.ResponseWriter, r *http.Request) (*models.Cursor, bool) {
+	value := r.URL.Query().Get("cursor")
+	if value == "" {
+		return nil, true
+	}
+
+	cursor, err := models.DecodeCursor(value)
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.INVALID_INPUT,
+			Data:    nil,
+		})
+		return nil, false
+	}
+	return &cursor, true
+}
+
+// renderCursorPage writes a page of a listing read with cursors, the
+// cursors of the pages around it are in the body and in a Link header
+func renderCursorPage(w http.ResponseWriter, r *http.Request, data any, cursors models.Cursors) {
+	links := []string{}
+	if cursors.Next != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, cursorUrl(r, cursors.Next)))
+	}
+	if cursors.Prev != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, cursorUrl(r, cursors.Prev)))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, message.Response{
+		Message: message.GET_DATA_SUCCESS,
+		Data:    data,
+		Cursors: cursors,
+	})
+}
+
+// cursorUrl returns the URL of the request reading the page of cursor
+func cursorUrl(r *http.Request, cursor string) string {
+	query := r.URL.Query()
+	query.Set("cursor", cursor)
+	return r.URL.Path + "?" + query.Encode()
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"api-chi/cmd/services"
+	"api-chi/internal/message"
+	"api-chi/internal/validate"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// StatusClientClosedRequest is the non standard status used when the client
+// went away before the response was ready
+const StatusClientClosedRequest = 499
+
+// errorStatus maps an error returned by a service to a status code, message
+// and data, failedMessage is used for errors that are not about the request itself
+func errorStatus(r *http.Request, err error, failedMessage string) (int, string, any) {
+	// Queries interrupted by the request context may come back as a
+	// Postgres error, so the context itself is checked as well
+	if ctxErr := r.Context().Err(); ctxErr != nil {
+		err = errors.Join(err, ctxErr)
+	}
+
+	invalidTag := &services.InvalidTagError{}
+	moved := &services.MovedError{}
+	validationErrors := validate.Errors{}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, message.REQUEST_TIMEOUT, nil
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, message.REQUEST_CANCELED, nil
+	case errors.As(err, &validationErrors):
+		return http.StatusUnprocessableEntity, message.VALIDATION_FAILED, validationErrors
+	case errors.As(err, &invalidTag):
+		return http.StatusUnprocessableEntity, message.INVALID_TAG, map[string]string{"tag_id": invalidTag.TagId}
+	case errors.Is(err, services.ErrInvalidReference):
+		return http.StatusUnprocessableEntity, message.INVALID_REFERENCE, nil
+	case errors.As(err, &moved):
+		return http.StatusMovedPermanently, message.MOVED, map[string]string{"slug": moved.Slug}
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound, message.NOT_FOUND, nil
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict, message.CONFLICT, nil
+	case errors.Is(err, services.ErrInvalidCursor):
+		return http.StatusBadRequest, message.INVALID_INPUT, nil
+	default:
+		return http.StatusInternalServerError, failedMessage, nil
+	}
+}
+
+// renderError writes the response for an error returned by a service
+func renderError(w http.ResponseWriter, r *http.Request, err error, failedMessage string) {
+	status, msg, data := errorStatus(r, err, failedMessage)
+	render.Status(r, status)
+	render.JSON(w, r, message.Response{
+		Message: msg,
+		Data:    data,
+	})
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"api-chi/cmd/models"
+	"api-chi/internal/message"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/render"
+)
+
+// readSort reads the sort and order parameters of a listing, sort must be
+// one of fields. It renders the error and returns false otherwise.
+func readSort(w http.ResponseWriter, r *http.Request, fields []string) (models.Sort, bool) {
+	value := models.Sort{
+		Field: r.URL.Query().Get("sort"),
+		Order: r.URL.Query().Get("order"),
+	}
+	validField := value.Field == "" || slices.Contains(fields, value.Field)
+	validOrder := value.Order == "" || value.Order == models.ORDER_ASC || value.Order == models.ORDER_DESC
+	if !validField || !validOrder {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, message.Response{
+			Message: message.INVALID_INPUT,
+			Data:    nil,
+		})
+		return models.Sort{}, false
+	}
+	return value, true
+}
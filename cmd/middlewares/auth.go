@@ -2,12 +2,25 @@ package middlewares
 
 import (
 	"api-chi/cmd/services"
+	"context"
 	"fmt"
 	"net/http"
 )
 
+type loggedInKey struct{}
+
+// IsLoggedIn reports whether CheckLogin or Identify found a valid token
+func IsLoggedIn(ctx context.Context) bool {
+	loggedIn, _ := ctx.Value(loggedInKey{}).(bool)
+	return loggedIn
+}
+
 type AuthMiddleware struct {
-	service services.AuthService
+	service *services.AuthService
+}
+
+func NewAuthMiddleware(service *services.AuthService) *AuthMiddleware {
+	return &AuthMiddleware{service: service}
 }
 
 func (m *AuthMiddleware) CheckLogin(next http.Handler) http.Handler {
@@ -29,6 +42,29 @@ func (m *AuthMiddleware) CheckLogin(next http.Handler) http.Handler {
 		}
 
 		// Proceed to the next handler if the token is valid
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), loggedInKey{}, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Identify lets every request through, remembering in the request context
+// whether it came with a valid token so that public routes can show more
+// to logged in callers
+func (m *AuthMiddleware) Identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("auth-token")
+		if err != nil || cookie.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		isValid, err := m.service.ValidateToken(cookie.Value)
+		if err != nil || !isValid {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), loggedInKey{}, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"api-chi/cmd/config"
+	"api-chi/cmd/services"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -23,8 +24,8 @@ func createTestToken(username string, expiration time.Duration, secretKey []byte
 }
 
 func Test_AuthMiddleware(t *testing.T) {
-	//authService := &services.AuthService{}
-	authMiddleware := AuthMiddleware{} //service: authService}
+	authService := services.NewAuthService(config.AuthConfig{SecretKey: "SECRET"})
+	authMiddleware := NewAuthMiddleware(authService)
 
 	// Mock a next handler to test middleware chain behavior
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -35,7 +36,7 @@ func Test_AuthMiddleware(t *testing.T) {
 
 	t.Run("Authorized request - valid token", func(t *testing.T) {
 		// Create a valid token
-		secretKey := []byte(config.AUTH_SECRET_KEY)
+		secretKey := []byte(authService.Config.SecretKey)
 		tokenString, err := createTestToken("admin", 1*time.Hour, secretKey)
 		assert.NoError(t, err)
 
@@ -69,7 +70,7 @@ func Test_AuthMiddleware(t *testing.T) {
 
 	t.Run("Unauthorized request - expired token", func(t *testing.T) {
 		// Create an expired token
-		secretKey := []byte(config.AUTH_SECRET_KEY)
+		secretKey := []byte(authService.Config.SecretKey)
 		tokenString, err := createTestToken("admin", -1*time.Hour, secretKey) // Token expired one hour ago
 		assert.NoError(t, err)
 
@@ -86,4 +87,39 @@ func Test_AuthMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 		assert.Contains(t, rr.Body.String(), "Unauthorized")
 	})
+
+	t.Run("Identify remembers valid token", func(t *testing.T) {
+		tokenString, err := createTestToken("admin", 1*time.Hour, []byte(authService.Config.SecretKey))
+		assert.NoError(t, err)
+		expiredString, err := createTestToken("admin", -1*time.Hour, []byte(authService.Config.SecretKey))
+		assert.NoError(t, err)
+
+		tests := []struct {
+			name     string
+			token    string
+			loggedIn bool
+		}{
+			{"no token", "", false},
+			{"valid token", tokenString, true},
+			{"expired token", expiredString, false},
+		}
+		for _, test := range tests {
+			loggedIn := false
+			handler := authMiddleware.Identify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				loggedIn = IsLoggedIn(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if test.token != "" {
+				req.AddCookie(&http.Cookie{Name: "auth-token", Value: test.token})
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			// Guests are let through as well
+			assert.Equal(t, http.StatusOK, rr.Code, test.name)
+			assert.Equal(t, test.loggedIn, loggedIn, test.name)
+		}
+	})
 }
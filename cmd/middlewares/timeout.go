@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// QueryTimeout bounds the time a route may spend on its queries. Services run
+// their queries with the request context, so they are canceled once the
+// timeout passes or the client goes away. A zero timeout disables it.
+func QueryTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// BlogPostArchiveYear is a year of the archive with the months it has posts
+// in, newest first. Months are numbered from 1 and counted in UTC.
+type BlogPostArchiveYear struct {
+	Year   int                    `json:"year"`
+	Count  int                    `json:"count"`
+	Months []BlogPostArchiveMonth `json:"months"`
+}
+
+// BlogPostArchiveMonth holds the posts of the month only when they are
+// asked for
+type BlogPostArchiveMonth struct {
+	Month int                   `json:"month"`
+	Count int                   `json:"count"`
+	Posts []BlogPostArchivePost `json:"posts,omitempty"`
+}
+
+type BlogPostArchivePost struct {
+	Id        string    `json:"id"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
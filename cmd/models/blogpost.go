@@ -1,43 +1,125 @@
 package models
 
-import "time"
+import (
+	"api-chi/internal/markdown"
+	"time"
+)
 
+// Statuses posts can be filtered by
+const (
+	STATUS_PUBLISHED = "published"
+	STATUS_DRAFT     = "draft"
+	STATUS_SCHEDULED = "scheduled"
+	STATUS_ALL       = "all"
+)
+
+// Formats a single post can be returned in
+const (
+	FORMAT_MARKDOWN = "markdown"
+	FORMAT_HTML     = "html"
+)
+
+// Ways the tags of a filter are matched
+const (
+	TAG_MODE_ALL = "all"
+	TAG_MODE_ANY = "any"
+)
+
+// BlogPostFilter selects the posts to count or list. The zero Status
+// means published, so drafts are only returned when asked for. Scheduled
+// posts are drafts with a PublishAt, they aren't part of the draft status.
+// The zero TagMode keeps posts having all of the Tags, posts having any of
+// the ExcludeTags are left out. Sort orders listings, counts ignore it.
+// From and To keep the posts created in [From, To), either may be nil.
+type BlogPostFilter struct {
+	Search      string
+	Tags        []BlogTag
+	TagMode     string
+	ExcludeTags []BlogTag
+	Status      string
+	Sort        Sort
+	From        *time.Time
+	To          *time.Time
+}
+
+// Slug of BlogPostCreated and BlogPostUpdated is optional, it is made from
+// the title when empty. Updates without a slug keep a custom one, only slugs
+// made from the previous title follow the title. Summary is optional too, it
+// replaces the excerpt made from the content.
 type BlogPostCreated struct {
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	IsDraft   bool      `json:"is_draft"`
-	Tags      []BlogTag `json:"tags"`
+	Title     string     `json:"title"`
+	Slug      string     `json:"slug"`
+	Summary   string     `json:"summary"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	IsDraft   bool       `json:"is_draft"`
+	PublishAt *time.Time `json:"publish_at"`
+	Tags      []BlogTag  `json:"tags"`
 }
 
 type BlogPostUpdated struct {
-	Id        string    `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	IsDraft   bool      `json:"is_draft"`
-	Tags      []BlogTag `json:"tags"`
+	Id        string     `json:"id"`
+	Title     string     `json:"title"`
+	Slug      string     `json:"slug"`
+	Summary   string     `json:"summary"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	IsDraft   bool       `json:"is_draft"`
+	PublishAt *time.Time `json:"publish_at"`
+	Tags      []BlogTag  `json:"tags"`
 }
 
+// Excerpt, WordCount and ReadingTimeMinutes of BlogPostWithTags and
+// BlogPostContentWithTags are computed when the post is saved. Snippet is
+// only set in search results, it is HTML escaped content with <mark>
+// elements around the matched words.
 type BlogPostWithTags struct {
-	Id        string    `json:"id"`
-	Title     string    `json:"title"`
-	Slug      string    `json:"slug"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	IsDraft   bool      `json:"is_draft"`
-	Tags      []BlogTag `json:"tags"`
+	Id                 string     `json:"id"`
+	Title              string     `json:"title"`
+	Slug               string     `json:"slug"`
+	Excerpt            string     `json:"excerpt"`
+	WordCount          int        `json:"word_count"`
+	ReadingTimeMinutes int        `json:"reading_time_minutes"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	IsDraft            bool       `json:"is_draft"`
+	PublishAt          *time.Time `json:"publish_at"`
+	Tags               []BlogTag  `json:"tags"`
+	Snippet            string     `json:"snippet,omitempty"`
 }
 
 type BlogPostContentWithTags struct {
-	Id        string    `json:"id"`
-	Title     string    `json:"title"`
-	Slug      string    `json:"slug"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	IsDraft   bool      `json:"is_draft"`
-	Tags      []BlogTag `json:"tags"`
+	Id                 string     `json:"id"`
+	Title              string     `json:"title"`
+	Slug               string     `json:"slug"`
+	Summary            string     `json:"summary"`
+	Excerpt            string     `json:"excerpt"`
+	WordCount          int        `json:"word_count"`
+	ReadingTimeMinutes int        `json:"reading_time_minutes"`
+	Content            string     `json:"content"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	IsDraft            bool       `json:"is_draft"`
+	PublishAt          *time.Time `json:"publish_at"`
+	Tags               []BlogTag  `json:"tags"`
+	Snippet            string     `json:"snippet,omitempty"`
+
+	// Series is only set on posts read with their slug
+	Series *BlogPostSeries `json:"series,omitempty"`
+}
+
+// BlogPostHtml is a post with its content rendered to sanitized HTML and
+// the table of contents of its headings
+type BlogPostHtml struct {
+	BlogPostContentWithTags
+	Html string             `json:"html"`
+	Toc  []markdown.Heading `json:"toc"`
+}
+
+// BlogPostPreview is a link to read one post before it is published
+type BlogPostPreview struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"api-chi/internal/diff"
+	"time"
+)
+
+// BlogPostRevision is the title and content a post had before an update
+type BlogPostRevision struct {
+	Id        string    `json:"id"`
+	PostId    string    `json:"post_id"`
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BlogPostRevisionContent struct {
+	Id        string    `json:"id"`
+	PostId    string    `json:"post_id"`
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BlogPostRevisionDiff lists the line changes from one revision to another,
+// an empty To is the current post
+type BlogPostRevisionDiff struct {
+	From    string      `json:"from"`
+	To      string      `json:"to"`
+	Title   []diff.Line `json:"title"`
+	Content []diff.Line `json:"content"`
+}
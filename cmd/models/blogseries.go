@@ -0,0 +1,59 @@
+package models
+
+// BlogSeries groups posts meant to be read in order, like the parts of a
+// tutorial
+type BlogSeries struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+}
+
+// BlogSeriesPart puts a post at a position of a series, positions order
+// the parts and don't need to follow each other
+type BlogSeriesPart struct {
+	PostId   string `json:"post_id"`
+	Position int    `json:"position"`
+}
+
+// BlogSeriesPost is a part of a series as listed with it
+type BlogSeriesPost struct {
+	Id       string `json:"id"`
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	Position int    `json:"position"`
+}
+
+type BlogSeriesWithPosts struct {
+	BlogSeries
+	Posts []BlogSeriesPost `json:"posts"`
+}
+
+// Slug of BlogSeriesCreated and BlogSeriesUpdated is optional, it is made
+// from the title when empty. Parts replace the parts of the series.
+type BlogSeriesCreated struct {
+	Title       string           `json:"title"`
+	Slug        string           `json:"slug"`
+	Description string           `json:"description"`
+	Parts       []BlogSeriesPart `json:"parts"`
+}
+
+type BlogSeriesUpdated struct {
+	Id          string           `json:"id"`
+	Title       string           `json:"title"`
+	Slug        string           `json:"slug"`
+	Description string           `json:"description"`
+	Parts       []BlogSeriesPart `json:"parts"`
+}
+
+// BlogPostSeries places a post in its series. Part counts from 1 and Total
+// counts the parts the caller may see, Prev and Next are nil at the ends.
+type BlogPostSeries struct {
+	Id    string          `json:"id"`
+	Title string          `json:"title"`
+	Slug  string          `json:"slug"`
+	Part  int             `json:"part"`
+	Total int             `json:"total"`
+	Prev  *BlogSeriesPost `json:"prev"`
+	Next  *BlogSeriesPost `json:"next"`
+}
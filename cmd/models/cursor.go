@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// Cursor points at a row of a listing, the page after it is read or the
+// page before it when Before is set. Keys are the values the row is sorted
+// by, ending with its id.
+type Cursor struct {
+	Sort   Sort     `json:"sort"`
+	Before bool     `json:"before,omitempty"`
+	Keys   []string `json:"keys"`
+}
+
+// Cursors of the pages around a page, empty when there is no such page
+type Cursors struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// Encode returns the cursor as an opaque string safe in URLs
+func (c Cursor) Encode() string {
+	value, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+// DecodeCursor reads a cursor made by Encode
+func DecodeCursor(value string) (Cursor, error) {
+	cursor := Cursor{}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
@@ -0,0 +1,11 @@
+package models
+
+// PageMeta describes a page of a listing read with a page number, Limit is
+// the one used once clamped to the range of the listing
+type PageMeta struct {
+	Total      int  `json:"total"`
+	Page       int  `json:"page"`
+	Limit      int  `json:"limit"`
+	TotalPages int  `json:"total_pages"`
+	HasNext    bool `json:"has_next"`
+}
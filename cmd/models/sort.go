@@ -0,0 +1,31 @@
+package models
+
+// Fields lists can be sorted by
+const (
+	SORT_CREATED_AT = "created_at"
+	SORT_UPDATED_AT = "updated_at"
+	SORT_TITLE      = "title"
+	SORT_RELEVANCE  = "relevance"
+	SORT_NAME       = "name"
+	SORT_USAGE      = "usage"
+)
+
+// Directions of a sort
+const (
+	ORDER_ASC  = "asc"
+	ORDER_DESC = "desc"
+)
+
+// POST_SORTS and TAG_SORTS are the fields posts and tags can be sorted by
+var (
+	POST_SORTS = []string{SORT_CREATED_AT, SORT_UPDATED_AT, SORT_TITLE, SORT_RELEVANCE}
+	TAG_SORTS  = []string{SORT_NAME, SORT_USAGE}
+)
+
+// Sort orders a list by Field in Order. The zero Field is the default
+// order of the list and the zero Order the usual direction of the field,
+// newest, most relevant or most used first and alphabetical otherwise.
+type Sort struct {
+	Field string `json:"field"`
+	Order string `json:"order"`
+}
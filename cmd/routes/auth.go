@@ -6,8 +6,8 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-func AuthRoutes(r chi.Router) {
-	controller := controllers.AuthController{}
+func AuthRoutes(r chi.Router, deps Dependencies) {
+	controller := controllers.NewAuthController(deps.Auth)
 
 	r.Route("/auth", func(r chi.Router) {
 		r.Get("/verify", controller.Verify)
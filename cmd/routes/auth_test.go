@@ -1,9 +1,7 @@
 package routes
 
 import (
-	"api-chi/cmd/config"
 	"api-chi/cmd/models"
-	"api-chi/cmd/services"
 	"api-chi/internal/message"
 	"time"
 
@@ -19,9 +17,8 @@ import (
 
 func Test_AuthRoutes(t *testing.T) {
 	r := chi.NewRouter()
-	AuthRoutes(r)
-	config.LoadAuthConfig()
-	service := services.AuthService{}
+	service := testAuthService()
+	AuthRoutes(r, Dependencies{Auth: service})
 
 	t.Run("Login success", func(t *testing.T) {
 		// Prepare input data
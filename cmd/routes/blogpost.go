@@ -3,22 +3,37 @@ package routes
 import (
 	"api-chi/cmd/controllers"
 	"api-chi/cmd/middlewares"
+	"api-chi/internal/markdown"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func BlogPostRoutes(r chi.Router) {
-	controller := controllers.BlogPostController{}
-	authMiddleware := middlewares.AuthMiddleware{}
+func BlogPostRoutes(r chi.Router, deps Dependencies) {
+	controller := controllers.NewBlogPostController(deps.Posts, deps.Auth, markdown.NewRenderer(markdown.CACHE_SIZE))
+	revisionController := controllers.NewBlogPostRevisionController(deps.Revisions, deps.Posts)
+	authMiddleware := middlewares.NewAuthMiddleware(deps.Auth)
+	readTimeout := middlewares.QueryTimeout(deps.ReadTimeout)
+	writeTimeout := middlewares.QueryTimeout(deps.WriteTimeout)
 
 	r.Route("/blog/posts", func(r chi.Router) {
-		r.Get("/count", controller.Count)
-		r.Get("/", controller.GetAll)
-		r.Get("/slug/{slug}", controller.GetWithSlug)
+		r.With(authMiddleware.Identify, readTimeout).Get("/count", controller.Count)
+		r.With(authMiddleware.Identify, readTimeout).Get("/", controller.GetAll)
+		r.With(authMiddleware.Identify, readTimeout).Get("/archive", controller.GetArchive)
+		r.With(authMiddleware.Identify, readTimeout).Get("/slug/{slug}", controller.GetWithSlug)
+		r.With(authMiddleware.Identify, readTimeout).Get("/slug/{slug}/related", controller.GetRelated)
+		r.With(readTimeout).Get("/preview/{token}", controller.GetPreview)
 
-		r.With(authMiddleware.CheckLogin).Get("/content", controller.GetAllWithContent)
-		r.With(authMiddleware.CheckLogin).Post("/", controller.Create)
-		r.With(authMiddleware.CheckLogin).Patch("/", controller.Update)
-		r.With(authMiddleware.CheckLogin).Delete("/{id}", controller.Remove)
+		r.With(authMiddleware.CheckLogin, readTimeout).Get("/content", controller.GetAllWithContent)
+		r.With(authMiddleware.CheckLogin, writeTimeout).Post("/", controller.Create)
+		r.With(authMiddleware.CheckLogin, writeTimeout).Post("/{id}/preview", controller.CreatePreview)
+		r.With(authMiddleware.CheckLogin, writeTimeout).Patch("/", controller.Update)
+		r.With(authMiddleware.CheckLogin, writeTimeout).Delete("/{id}", controller.Remove)
+
+		r.Route("/{id}/revisions", func(r chi.Router) {
+			r.With(authMiddleware.CheckLogin, readTimeout).Get("/", revisionController.GetAll)
+			r.With(authMiddleware.CheckLogin, readTimeout).Get("/diff", revisionController.Diff)
+			r.With(authMiddleware.CheckLogin, readTimeout).Get("/{revisionId}", revisionController.Get)
+			r.With(authMiddleware.CheckLogin, writeTimeout).Post("/{revisionId}/restore", revisionController.Restore)
+		})
 	})
 }
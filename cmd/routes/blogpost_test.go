@@ -1,10 +1,13 @@
 package routes
 
 import (
+	"api-chi/cmd/config"
+	"api-chi/cmd/controllers"
 	"api-chi/cmd/models"
 	"api-chi/cmd/services"
 	"api-chi/internal/message"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,10 +21,11 @@ import (
 
 func Test_BlogPostRoutes(t *testing.T) {
 	r := chi.NewRouter()
-	BlogPostRoutes(r)
+	service := testAuthService()
+	posts := services.NewMemoryBlogPostService(services.NewMemoryStore())
+	BlogPostRoutes(r, Dependencies{Auth: service, Posts: posts})
 	id := ""
 	slug := ""
-	service := services.AuthService{}
 	token, _ := service.GenerateToken(&models.Auth{Username: "admin"})
 
 	authCookie := &http.Cookie{
@@ -66,6 +70,11 @@ func Test_BlogPostRoutes(t *testing.T) {
 		// Extract slug from the BlogPost data
 		slug = dataMap["slug"].(string)
 		assert.NotEmpty(t, slug)
+
+		// Stats are computed from the content
+		assert.Equal(t, "Hello new post!", dataMap["excerpt"])
+		assert.Equal(t, float64(3), dataMap["word_count"])
+		assert.Equal(t, float64(1), dataMap["reading_time_minutes"])
 	})
 
 	t.Run("Count success", func(t *testing.T) {
@@ -82,8 +91,44 @@ func Test_BlogPostRoutes(t *testing.T) {
 		assert.NotNil(t, response.Data)
 	})
 
+	t.Run("Count failed when client went away", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest("GET", "/blog/posts/count", nil).WithContext(ctx)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, controllers.StatusClientClosedRequest, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.REQUEST_CANCELED, response.Message)
+	})
+
+	t.Run("GetAll failed on timeout", func(t *testing.T) {
+		r := chi.NewRouter()
+		BlogPostRoutes(r, Dependencies{
+			Auth:        service,
+			Posts:       services.NewMemoryBlogPostService(services.NewMemoryStore()),
+			ReadTimeout: time.Nanosecond,
+		})
+
+		req := httptest.NewRequest("GET", "/blog/posts?limit=10&page=1", nil)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusGatewayTimeout, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.REQUEST_TIMEOUT, response.Message)
+	})
+
 	t.Run("Get with slug success", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/blog/posts/slug/"+slug, nil)
+		req.AddCookie(authCookie)
 		res := httptest.NewRecorder()
 
 		r.ServeHTTP(res, req)
@@ -96,6 +141,179 @@ func Test_BlogPostRoutes(t *testing.T) {
 		assert.NotNil(t, response.Data)
 	})
 
+	t.Run("Get with slug success as HTML", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/blog/posts/slug/"+slug+"?format=html", nil)
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		dataMap := response.Data.(map[string]any)
+		assert.Equal(t, "## Hello new post!", dataMap["content"])
+		assert.Equal(t, "<h2 id=\"hello-new-post\">Hello new post!</h2>\n", dataMap["html"])
+		assert.Equal(t, []any{
+			map[string]any{"level": float64(2), "id": "hello-new-post", "text": "Hello new post!"},
+		}, dataMap["toc"])
+
+		// Unknown formats are rejected
+		req = httptest.NewRequest("GET", "/blog/posts/slug/"+slug+"?format=pdf", nil)
+		req.AddCookie(authCookie)
+		res = httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("Get with slug failed for guest on draft", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/blog/posts/slug/"+slug, nil)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.NOT_FOUND, response.Message)
+	})
+
+	t.Run("Count hides drafts from guests", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			query  string
+			login  bool
+			status int
+			count  float64
+		}{
+			{name: "guest", query: "", login: false, status: http.StatusOK, count: 0},
+			{name: "guest published", query: "?status=published", login: false, status: http.StatusOK, count: 0},
+			{name: "guest draft", query: "?status=draft", login: false, status: http.StatusUnauthorized},
+			{name: "admin", query: "", login: true, status: http.StatusOK, count: 1},
+			{name: "admin draft", query: "?status=draft", login: true, status: http.StatusOK, count: 1},
+			{name: "admin published", query: "?status=published", login: true, status: http.StatusOK, count: 0},
+			{name: "admin scheduled", query: "?status=scheduled", login: true, status: http.StatusOK, count: 0},
+			{name: "guest scheduled", query: "?status=scheduled", login: false, status: http.StatusUnauthorized},
+			{name: "invalid status", query: "?status=hidden", login: true, status: http.StatusBadRequest},
+			{name: "admin any tag", query: "?tags=missing%3Bother&tag_mode=any", login: true, status: http.StatusOK, count: 0},
+			{name: "admin excluded tag", query: "?exclude_tags=missing", login: true, status: http.StatusOK, count: 1},
+			{name: "invalid tag mode", query: "?tag_mode=some", login: true, status: http.StatusBadRequest},
+			{name: "invalid sort", query: "?sort=usage", login: true, status: http.StatusBadRequest},
+			{name: "invalid order", query: "?sort=title&order=up", login: true, status: http.StatusBadRequest},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				req := httptest.NewRequest("GET", "/blog/posts/count"+test.query, nil)
+				if test.login {
+					req.AddCookie(authCookie)
+				}
+				res := httptest.NewRecorder()
+
+				r.ServeHTTP(res, req)
+
+				assert.Equal(t, test.status, res.Code)
+				if test.status != http.StatusOK {
+					return
+				}
+				var response message.Response
+				err := json.NewDecoder(res.Body).Decode(&response)
+				assert.NoError(t, err)
+				assert.Equal(t, test.count, response.Data)
+			})
+		}
+	})
+
+	t.Run("Get with slug failed with unknown slug", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/blog/posts/slug/unknown-slug", nil)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.NOT_FOUND, response.Message)
+		assert.Nil(t, response.Data)
+	})
+
+	t.Run("Preview draft success", func(t *testing.T) {
+		// Mint a preview link for the draft
+		req := httptest.NewRequest("POST", "/blog/posts/"+id+"/preview", nil)
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.CREATE_DATA_SUCCESS, response.Message)
+		dataMap, ok := response.Data.(map[string]any)
+		if !ok {
+			t.Fatalf("Expected response.Data to be a map, got %T", response.Data)
+		}
+		previewToken := dataMap["token"].(string)
+		assert.NotEmpty(t, previewToken)
+		assert.NotEmpty(t, dataMap["expires_at"])
+
+		// Guests read the draft with the token
+		req = httptest.NewRequest("GET", "/blog/posts/preview/"+previewToken, nil)
+		res = httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		err = json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.GET_DATA_SUCCESS, response.Message)
+		assert.Equal(t, id, response.Data.(map[string]any)["id"])
+	})
+
+	t.Run("Preview failed", func(t *testing.T) {
+		expiredToken, _, err := services.NewAuthService(config.AuthConfig{SecretKey: "SECRET", PreviewTtl: -time.Hour}).GeneratePreviewToken(id)
+		assert.NoError(t, err)
+		unknownToken, _, err := service.GeneratePreviewToken("00000000-0000-4000-8000-000000000000")
+		assert.NoError(t, err)
+		published, err := posts.Create(context.Background(), &models.BlogPostCreated{Title: "published preview"})
+		assert.NoError(t, err)
+		defer posts.Remove(context.Background(), published.Id)
+
+		tests := []struct {
+			name   string
+			method string
+			target string
+			login  bool
+			status int
+		}{
+			{name: "guest can't mint", method: "POST", target: "/blog/posts/" + id + "/preview", status: http.StatusUnauthorized},
+			{name: "mint unknown post", method: "POST", target: "/blog/posts/00000000-0000-4000-8000-000000000000/preview", login: true, status: http.StatusNotFound},
+			{name: "mint published post", method: "POST", target: "/blog/posts/" + published.Id + "/preview", login: true, status: http.StatusConflict},
+			{name: "invalid token", method: "GET", target: "/blog/posts/preview/invalid", status: http.StatusUnauthorized},
+			{name: "login token", method: "GET", target: "/blog/posts/preview/" + token, status: http.StatusUnauthorized},
+			{name: "expired token", method: "GET", target: "/blog/posts/preview/" + expiredToken, status: http.StatusUnauthorized},
+			{name: "removed post", method: "GET", target: "/blog/posts/preview/" + unknownToken, status: http.StatusNotFound},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				req := httptest.NewRequest(test.method, test.target, nil)
+				if test.login {
+					req.AddCookie(authCookie)
+				}
+				res := httptest.NewRecorder()
+
+				r.ServeHTTP(res, req)
+
+				assert.Equal(t, test.status, res.Code)
+			})
+		}
+	})
+
 	t.Run("GetAll success", func(t *testing.T) {
 		search := ""
 		limit := 10
@@ -112,6 +330,62 @@ func Test_BlogPostRoutes(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, message.GET_DATA_SUCCESS, response.Message)
 		assert.NotNil(t, response.Data)
+
+		// The limit used once clamped is in the meta
+		meta := response.Meta.(map[string]any)
+		assert.Equal(t, float64(limit), meta["limit"])
+		assert.Equal(t, float64(page), meta["page"])
+		assert.Contains(t, meta, "total")
+		assert.Contains(t, meta, "total_pages")
+		assert.Contains(t, meta, "has_next")
+	})
+
+	t.Run("GetAll with cursors", func(t *testing.T) {
+		tests := []struct {
+			query  string
+			status int
+		}{
+			{"limit=10", http.StatusOK},
+			{"limit=10&sort=title&cursor=" + models.Cursor{Sort: models.Sort{Field: models.SORT_TITLE}, Keys: []string{"a", "b"}}.Encode(), http.StatusOK},
+			{"limit=10&cursor=not-a-cursor", http.StatusBadRequest},
+			{"limit=10&cursor=" + models.Cursor{Keys: []string{"yesterday"}}.Encode(), http.StatusBadRequest},
+			{"cursor=", http.StatusBadRequest},
+		}
+		for _, test := range tests {
+			req := httptest.NewRequest("GET", "/blog/posts?"+test.query, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+
+			assert.Equal(t, test.status, res.Code, test.query)
+		}
+	})
+
+	t.Run("GetArchive success", func(t *testing.T) {
+		tests := []struct {
+			query  string
+			login  bool
+			status int
+		}{
+			{"", false, http.StatusOK},
+			{"?posts=true&from=2024-01-01&to=2024-12-31", false, http.StatusOK},
+			{"?status=draft", false, http.StatusUnauthorized},
+			{"?status=all", true, http.StatusOK},
+			{"?posts=maybe", false, http.StatusBadRequest},
+			{"?from=last-week", false, http.StatusBadRequest},
+			{"?from=2024-02-01&to=2024-01-31", false, http.StatusBadRequest},
+		}
+		for _, test := range tests {
+			req := httptest.NewRequest("GET", "/blog/posts/archive"+test.query, nil)
+			if test.login {
+				req.AddCookie(authCookie)
+			}
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+
+			assert.Equal(t, test.status, res.Code, test.query)
+		}
 	})
 
 	t.Run("GetAllWithContent success", func(t *testing.T) {
@@ -162,6 +436,167 @@ func Test_BlogPostRoutes(t *testing.T) {
 		assert.NotNil(t, response.Data)
 	})
 
+	t.Run("Get with old slug redirects", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/blog/posts/slug/"+slug, nil)
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusMovedPermanently, res.Code)
+		assert.Equal(t, "/blog/posts/slug/my-test-post", res.Header().Get("Location"))
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.MOVED, response.Message)
+		assert.Equal(t, map[string]any{"slug": "my-test-post"}, response.Data)
+
+		// Guests don't learn where a draft went
+		req = httptest.NewRequest("GET", "/blog/posts/slug/"+slug, nil)
+		res = httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		assert.Empty(t, res.Header().Get("Location"))
+	})
+
+	t.Run("GetRelated success", func(t *testing.T) {
+		tests := []struct {
+			target   string
+			login    bool
+			status   int
+			location string
+		}{
+			{"/blog/posts/slug/my-test-post/related", true, http.StatusOK, ""},
+			{"/blog/posts/slug/my-test-post/related?limit=3", true, http.StatusOK, ""},
+			{"/blog/posts/slug/my-test-post/related?limit=many", true, http.StatusBadRequest, ""},
+			{"/blog/posts/slug/" + slug + "/related", true, http.StatusMovedPermanently, "/blog/posts/slug/my-test-post/related"},
+			{"/blog/posts/slug/my-test-post/related", false, http.StatusNotFound, ""},
+			{"/blog/posts/slug/unknown/related", true, http.StatusNotFound, ""},
+		}
+		for _, test := range tests {
+			req := httptest.NewRequest("GET", test.target, nil)
+			if test.login {
+				req.AddCookie(authCookie)
+			}
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+
+			assert.Equal(t, test.status, res.Code, test.target)
+			assert.Equal(t, test.location, res.Header().Get("Location"), test.target)
+			if test.status == http.StatusOK {
+				var response message.Response
+				err := json.NewDecoder(res.Body).Decode(&response)
+				assert.NoError(t, err)
+				assert.Equal(t, []any{}, response.Data, test.target)
+			}
+		}
+	})
+
+	t.Run("Create failed with invalid tag", func(t *testing.T) {
+		input := models.BlogPostCreated{
+			Title:     "invalid tag post",
+			Content:   "## Hello invalid tag post!",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			IsDraft:   true,
+			Tags:      []models.BlogTag{{Id: "00000000-0000-4000-8000-000000000000"}},
+		}
+		body, _ := json.Marshal(input)
+
+		req := httptest.NewRequest("POST", "/blog/posts", bytes.NewBuffer(body))
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.INVALID_TAG, response.Message)
+		assert.Equal(t, map[string]any{"tag_id": "00000000-0000-4000-8000-000000000000"}, response.Data)
+	})
+
+	t.Run("Create failed with invalid fields", func(t *testing.T) {
+		input := models.BlogPostCreated{
+			Title:   "",
+			Content: "## Hello!",
+			IsDraft: true,
+			Tags:    []models.BlogTag{{Id: "not-a-uuid"}},
+		}
+		body, _ := json.Marshal(input)
+
+		req := httptest.NewRequest("POST", "/blog/posts", bytes.NewBuffer(body))
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.VALIDATION_FAILED, response.Message)
+		assert.Equal(t, []any{
+			map[string]any{"field": "title", "rule": "required"},
+			map[string]any{"field": "created_at", "rule": "not_zero"},
+			map[string]any{"field": "updated_at", "rule": "not_zero"},
+			map[string]any{"field": "tags[0].id", "rule": "uuid"},
+		}, response.Data)
+	})
+
+	t.Run("Create with slug", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			title  string
+			slug   string
+			status int
+			want   string
+		}{
+			{name: "colliding title", title: "My test post", status: http.StatusOK, want: "my-test-post-2"},
+			{name: "custom slug", title: "My test post", slug: "custom-slug", status: http.StatusOK, want: "custom-slug"},
+			{name: "duplicate custom slug", title: "Another post", slug: "custom-slug", status: http.StatusConflict},
+			{name: "reserved custom slug", title: "Another post", slug: "preview", status: http.StatusUnprocessableEntity},
+		}
+		created := []string{}
+		for _, test := range tests {
+			input := models.BlogPostCreated{
+				Title:     test.title,
+				Slug:      test.slug,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+			body, _ := json.Marshal(input)
+
+			req := httptest.NewRequest("POST", "/blog/posts", bytes.NewBuffer(body))
+			req.AddCookie(authCookie)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+
+			assert.Equal(t, test.status, res.Code, test.name)
+			if test.status != http.StatusOK {
+				continue
+			}
+			var response message.Response
+			err := json.NewDecoder(res.Body).Decode(&response)
+			assert.NoError(t, err)
+			dataMap := response.Data.(map[string]any)
+			assert.Equal(t, test.want, dataMap["slug"], test.name)
+			created = append(created, dataMap["id"].(string))
+		}
+
+		// Leave the list as the other tests expect it
+		for _, createdId := range created {
+			req := httptest.NewRequest("DELETE", "/blog/posts/"+createdId, nil)
+			req.AddCookie(authCookie)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	})
+
 	t.Run("Remove success", func(t *testing.T) {
 		if id == "" {
 			t.Fatal("ID must be set before running Remove test")
@@ -180,4 +615,18 @@ func Test_BlogPostRoutes(t *testing.T) {
 		assert.Equal(t, message.REMOVE_DATA_SUCCESS, response.Message)
 		assert.NotNil(t, response.Data)
 	})
+
+	t.Run("Remove failed with unknown id", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/blog/posts/"+id, nil)
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.NOT_FOUND, response.Message)
+	})
 }
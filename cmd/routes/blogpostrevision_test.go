@@ -0,0 +1,181 @@
+package routes
+
+import (
+	"api-chi/cmd/models"
+	"api-chi/cmd/services"
+	"api-chi/internal/diff"
+	"api-chi/internal/message"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BlogPostRevisionRoutes(t *testing.T) {
+	r := chi.NewRouter()
+	service := testAuthService()
+	store := services.NewMemoryStore()
+	posts := services.NewMemoryBlogPostService(store)
+	BlogPostRoutes(r, Dependencies{
+		Auth:      service,
+		Posts:     posts,
+		Revisions: services.NewMemoryBlogPostRevisionService(store),
+	})
+	token, _ := service.GenerateToken(&models.Auth{Username: "admin"})
+
+	authCookie := &http.Cookie{
+		Name:  "auth-token",
+		Value: token,
+	}
+
+	post, err := posts.Create(context.Background(), &models.BlogPostCreated{
+		Title:     "first title",
+		Content:   "one\ntwo",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	assert.NoError(t, err)
+	revisionId := ""
+
+	// serve sends a logged in request and decodes the response
+	serve := func(t *testing.T, method string, target string, body any) (int, message.Response) {
+		buffer := &bytes.Buffer{}
+		if body != nil {
+			_ = json.NewEncoder(buffer).Encode(body)
+		}
+		req := httptest.NewRequest(method, target, buffer)
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		return res.Code, response
+	}
+
+	t.Run("GetAll success", func(t *testing.T) {
+		code, _ := serve(t, "PATCH", "/blog/posts", models.BlogPostUpdated{
+			Id:        post.Id,
+			Title:     "second title",
+			Content:   "one\n2\nthree",
+			CreatedAt: post.CreatedAt,
+			UpdatedAt: time.Now(),
+		})
+		assert.Equal(t, http.StatusOK, code)
+
+		code, response := serve(t, "GET", "/blog/posts/"+post.Id+"/revisions", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, message.GET_DATA_SUCCESS, response.Message)
+		data := response.Data.([]any)
+		assert.Equal(t, 1, len(data))
+		revision := data[0].(map[string]any)
+		assert.Equal(t, "first title", revision["title"])
+		assert.Nil(t, revision["content"])
+
+		revisionId = revision["id"].(string)
+	})
+
+	t.Run("Get success", func(t *testing.T) {
+		code, response := serve(t, "GET", "/blog/posts/"+post.Id+"/revisions/"+revisionId, nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "one\ntwo", response.Data.(map[string]any)["content"])
+	})
+
+	t.Run("Diff success", func(t *testing.T) {
+		code, response := serve(t, "GET", "/blog/posts/"+post.Id+"/revisions/diff?from="+revisionId, nil)
+		assert.Equal(t, http.StatusOK, code)
+
+		// Compare through JSON like a client would
+		body, _ := json.Marshal(response.Data)
+		data := models.BlogPostRevisionDiff{}
+		assert.NoError(t, json.Unmarshal(body, &data))
+		assert.Equal(t, []diff.Line{
+			{Op: diff.EQUAL, Text: "one"},
+			{Op: diff.DELETE, Text: "two"},
+			{Op: diff.INSERT, Text: "2"},
+			{Op: diff.INSERT, Text: "three"},
+		}, data.Content)
+		assert.Equal(t, []diff.Line{
+			{Op: diff.DELETE, Text: "first title"},
+			{Op: diff.INSERT, Text: "second title"},
+		}, data.Title)
+	})
+
+	t.Run("Restore success", func(t *testing.T) {
+		code, response := serve(t, "POST", "/blog/posts/"+post.Id+"/revisions/"+revisionId+"/restore", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, message.UPDATE_DATA_SUCCESS, response.Message)
+		assert.Equal(t, "one\ntwo", response.Data.(map[string]any)["content"])
+		assert.Equal(t, "first title", response.Data.(map[string]any)["title"])
+
+		// The slug was made from the title, so it follows the restored one
+		assert.Equal(t, "first-title", response.Data.(map[string]any)["slug"])
+
+		// The restored content was saved as a revision as well
+		_, response = serve(t, "GET", "/blog/posts/"+post.Id+"/revisions", nil)
+		data := response.Data.([]any)
+		assert.Equal(t, 2, len(data))
+		assert.Equal(t, "second title", data[0].(map[string]any)["title"])
+	})
+
+	t.Run("Update after restore follows the title", func(t *testing.T) {
+		code, response := serve(t, "PATCH", "/blog/posts", models.BlogPostUpdated{
+			Id:        post.Id,
+			Title:     "third title",
+			Content:   "one\ntwo",
+			CreatedAt: post.CreatedAt,
+			UpdatedAt: time.Now(),
+		})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "third-title", response.Data.(map[string]any)["slug"])
+	})
+
+	t.Run("Restore success keeps custom slug", func(t *testing.T) {
+		code, _ := serve(t, "PATCH", "/blog/posts", models.BlogPostUpdated{
+			Id:        post.Id,
+			Title:     "third title",
+			Slug:      "my-own-slug",
+			Content:   "one\ntwo",
+			CreatedAt: post.CreatedAt,
+			UpdatedAt: time.Now(),
+		})
+		assert.Equal(t, http.StatusOK, code)
+
+		code, response := serve(t, "POST", "/blog/posts/"+post.Id+"/revisions/"+revisionId+"/restore", nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "first title", response.Data.(map[string]any)["title"])
+		assert.Equal(t, "my-own-slug", response.Data.(map[string]any)["slug"])
+	})
+
+	t.Run("Revisions failed", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			method string
+			target string
+			status int
+		}{
+			{"unknown revision", "GET", "/blog/posts/" + post.Id + "/revisions/00000000-0000-4000-8000-000000000000", http.StatusNotFound},
+			{"revision of another post", "GET", "/blog/posts/00000000-0000-4000-8000-000000000000/revisions/" + revisionId, http.StatusNotFound},
+			{"diff without from", "GET", "/blog/posts/" + post.Id + "/revisions/diff", http.StatusBadRequest},
+			{"restore unknown revision", "POST", "/blog/posts/" + post.Id + "/revisions/00000000-0000-4000-8000-000000000000/restore", http.StatusNotFound},
+		}
+		for _, test := range tests {
+			code, _ := serve(t, test.method, test.target, nil)
+			assert.Equal(t, test.status, code, test.name)
+		}
+
+		// Guests don't see revisions
+		req := httptest.NewRequest("GET", "/blog/posts/"+post.Id+"/revisions", nil)
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"api-chi/cmd/controllers"
+	"api-chi/cmd/middlewares"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func BlogSeriesRoutes(r chi.Router, deps Dependencies) {
+	controller := controllers.NewBlogSeriesController(deps.Series)
+	authMiddleware := middlewares.NewAuthMiddleware(deps.Auth)
+	readTimeout := middlewares.QueryTimeout(deps.ReadTimeout)
+	writeTimeout := middlewares.QueryTimeout(deps.WriteTimeout)
+
+	r.Route("/blog/series", func(r chi.Router) {
+		r.With(readTimeout).Get("/", controller.GetAll)
+		r.With(authMiddleware.Identify, readTimeout).Get("/slug/{slug}", controller.GetWithSlug)
+
+		r.With(authMiddleware.CheckLogin, writeTimeout).Post("/", controller.Create)
+		r.With(authMiddleware.CheckLogin, writeTimeout).Patch("/", controller.Update)
+		r.With(authMiddleware.CheckLogin, writeTimeout).Delete("/{id}", controller.Remove)
+	})
+}
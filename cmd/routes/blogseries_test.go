@@ -0,0 +1,223 @@
+package routes
+
+import (
+	"api-chi/cmd/models"
+	"api-chi/cmd/services"
+	"api-chi/internal/message"
+
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BlogSeriesRoutes(t *testing.T) {
+	r := chi.NewRouter()
+	service := testAuthService()
+	store := services.NewMemoryStore()
+	posts := services.NewMemoryBlogPostService(store)
+	BlogSeriesRoutes(r, Dependencies{Auth: service, Series: services.NewMemoryBlogSeriesService(store)})
+	id := ""
+	token, _ := service.GenerateToken(&models.Auth{Username: "admin"})
+
+	authCookie := &http.Cookie{
+		Name:  "auth-token",
+		Value: token,
+	}
+
+	first, err := posts.Create(context.Background(), &models.BlogPostCreated{Title: "first part"})
+	assert.NoError(t, err)
+	draft, err := posts.Create(context.Background(), &models.BlogPostCreated{Title: "draft part", IsDraft: true})
+	assert.NoError(t, err)
+
+	t.Run("Create success", func(t *testing.T) {
+		input := models.BlogSeriesCreated{
+			Title: "new series",
+			Parts: []models.BlogSeriesPart{
+				{PostId: first.Id, Position: 1},
+				{PostId: draft.Id, Position: 2},
+			},
+		}
+		body, _ := json.Marshal(input)
+
+		req := httptest.NewRequest("POST", "/blog/series", bytes.NewBuffer(body))
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.CREATE_DATA_SUCCESS, response.Message)
+
+		dataMap := response.Data.(map[string]any)
+		assert.Equal(t, "new-series", dataMap["slug"])
+		assert.Equal(t, 2, len(dataMap["posts"].([]any)))
+		id = dataMap["id"].(string)
+	})
+
+	t.Run("Create failed without login", func(t *testing.T) {
+		body, _ := json.Marshal(models.BlogSeriesCreated{Title: "other series"})
+
+		req := httptest.NewRequest("POST", "/blog/series", bytes.NewBuffer(body))
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+
+	t.Run("Create failed with invalid parts", func(t *testing.T) {
+		input := models.BlogSeriesCreated{
+			Title: "other series",
+			Parts: []models.BlogSeriesPart{
+				{PostId: first.Id, Position: 0},
+				{PostId: first.Id, Position: 2},
+			},
+		}
+		body, _ := json.Marshal(input)
+
+		req := httptest.NewRequest("POST", "/blog/series", bytes.NewBuffer(body))
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.VALIDATION_FAILED, response.Message)
+		assert.Equal(t, []any{
+			map[string]any{"field": "parts[0].position", "rule": "min", "limit": float64(1)},
+			map[string]any{"field": "parts[1].post_id", "rule": "unique"},
+		}, response.Data)
+	})
+
+	t.Run("Create failed with post of another series", func(t *testing.T) {
+		input := models.BlogSeriesCreated{
+			Title: "other series",
+			Parts: []models.BlogSeriesPart{{PostId: first.Id, Position: 1}},
+		}
+		body, _ := json.Marshal(input)
+
+		req := httptest.NewRequest("POST", "/blog/series", bytes.NewBuffer(body))
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("GetAll success", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/blog/series", nil)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.GET_DATA_SUCCESS, response.Message)
+		assert.Equal(t, 1, len(response.Data.([]any)))
+	})
+
+	t.Run("GetWithSlug success", func(t *testing.T) {
+		// Drafts are only listed for logged in callers
+		tests := []struct {
+			cookie *http.Cookie
+			parts  int
+		}{
+			{nil, 1},
+			{authCookie, 2},
+		}
+		for _, test := range tests {
+			req := httptest.NewRequest("GET", "/blog/series/slug/new-series", nil)
+			if test.cookie != nil {
+				req.AddCookie(test.cookie)
+			}
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusOK, res.Code)
+			var response message.Response
+			err := json.NewDecoder(res.Body).Decode(&response)
+			assert.NoError(t, err)
+			assert.Equal(t, test.parts, len(response.Data.(map[string]any)["posts"].([]any)))
+		}
+	})
+
+	t.Run("GetWithSlug failed with unknown slug", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/blog/series/slug/unknown", nil)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("Update success", func(t *testing.T) {
+		if id == "" {
+			t.Fatal("ID must be set before running Update test")
+		}
+
+		input := models.BlogSeriesUpdated{
+			Id:          id,
+			Title:       "updated series",
+			Description: "updated description",
+			Parts:       []models.BlogSeriesPart{{PostId: first.Id, Position: 1}},
+		}
+		body, _ := json.Marshal(input)
+
+		req := httptest.NewRequest("PATCH", "/blog/series", bytes.NewBuffer(body))
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.UPDATE_DATA_SUCCESS, response.Message)
+		assert.Equal(t, "updated-series", response.Data.(map[string]any)["slug"])
+	})
+
+	t.Run("Remove success", func(t *testing.T) {
+		if id == "" {
+			t.Fatal("ID must be set before running Remove test")
+		}
+
+		req := httptest.NewRequest("DELETE", "/blog/series/"+id, nil)
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.REMOVE_DATA_SUCCESS, response.Message)
+	})
+
+	t.Run("Remove failed with unknown id", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/blog/series/"+id, nil)
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
@@ -7,16 +7,18 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-func BlogTagRoutes(r chi.Router) {
-	controller := controllers.BlogTagController{}
-	authMiddleware := middlewares.AuthMiddleware{}
+func BlogTagRoutes(r chi.Router, deps Dependencies) {
+	controller := controllers.NewBlogTagController(deps.Tags)
+	authMiddleware := middlewares.NewAuthMiddleware(deps.Auth)
+	readTimeout := middlewares.QueryTimeout(deps.ReadTimeout)
+	writeTimeout := middlewares.QueryTimeout(deps.WriteTimeout)
 
 	r.Route("/blog/tags", func(r chi.Router) {
-		r.Get("/count", controller.Count)
-		r.Get("/", controller.GetAll)
+		r.With(readTimeout).Get("/count", controller.Count)
+		r.With(readTimeout).Get("/", controller.GetAll)
 
-		r.With(authMiddleware.CheckLogin).Post("/", controller.Create)
-		r.With(authMiddleware.CheckLogin).Patch("/", controller.Update)
-		r.With(authMiddleware.CheckLogin).Delete("/{id}", controller.Remove)
+		r.With(authMiddleware.CheckLogin, writeTimeout).Post("/", controller.Create)
+		r.With(authMiddleware.CheckLogin, writeTimeout).Patch("/", controller.Update)
+		r.With(authMiddleware.CheckLogin, writeTimeout).Delete("/{id}", controller.Remove)
 	})
 }
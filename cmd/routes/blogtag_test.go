@@ -6,6 +6,7 @@ import (
 	"api-chi/internal/message"
 
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,9 +19,10 @@ import (
 
 func Test_BlogTagRoutes(t *testing.T) {
 	r := chi.NewRouter()
-	BlogTagRoutes(r)
+	service := testAuthService()
+	tags := services.NewMemoryBlogTagService(services.NewMemoryStore())
+	BlogTagRoutes(r, Dependencies{Auth: service, Tags: tags})
 	id := ""
-	service := services.AuthService{}
 	token, _ := service.GenerateToken(&models.Auth{Username: "admin"})
 
 	authCookie := &http.Cookie{
@@ -60,6 +62,89 @@ func Test_BlogTagRoutes(t *testing.T) {
 		assert.NotNil(t, response.Data)
 	})
 
+	t.Run("GetAll with sort", func(t *testing.T) {
+		tests := []struct {
+			query  string
+			status int
+		}{
+			{"&sort=usage&order=desc", http.StatusOK},
+			{"&sort=name", http.StatusOK},
+			{"&sort=title", http.StatusBadRequest},
+			{"&sort=name&order=up", http.StatusBadRequest},
+		}
+		for _, test := range tests {
+			req := httptest.NewRequest("GET", "/blog/tags?limit=10&page=1"+test.query, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+
+			assert.Equal(t, test.status, res.Code, test.query)
+		}
+	})
+
+	t.Run("GetAll failed with invalid limit", func(t *testing.T) {
+		for _, query := range []string{"", "limit=ten", "limit=0", "limit=-1&page=1", "limit=-1"} {
+			req := httptest.NewRequest("GET", "/blog/tags?"+query, nil)
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusBadRequest, res.Code, query)
+			var response message.Response
+			err := json.NewDecoder(res.Body).Decode(&response)
+			assert.NoError(t, err)
+			assert.Equal(t, message.INVALID_INPUT, response.Message, query)
+		}
+	})
+
+	t.Run("GetAll with cursors", func(t *testing.T) {
+		for _, name := range []string{"cursor a", "cursor b", "cursor c"} {
+			tag, err := tags.Create(context.Background(), &models.BlogTag{Name: name})
+			assert.NoError(t, err)
+			defer tags.Remove(context.Background(), tag.Id)
+		}
+
+		// Without a page number the first page links to the next one
+		req := httptest.NewRequest("GET", "/blog/tags?search=cursor&limit=1", nil)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		cursors := response.Cursors.(map[string]any)
+		assert.NotEmpty(t, cursors["next"])
+		assert.Nil(t, cursors["prev"])
+		next := fmt.Sprintf("/blog/tags?cursor=%s&limit=1&search=cursor", cursors["next"])
+		assert.Equal(t, fmt.Sprintf(`<%s>; rel="next"`, next), res.Header().Get("Link"))
+
+		// The next page links both ways
+		req = httptest.NewRequest("GET", next, nil)
+		res = httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		response = message.Response{}
+		err = json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, "cursor b", response.Data.([]any)[0].(map[string]any)["name"])
+		assert.Contains(t, res.Header().Get("Link"), `rel="next"`)
+		assert.Contains(t, res.Header().Get("Link"), `rel="prev"`)
+
+		// Malformed cursors are refused
+		for _, cursor := range []string{"%25%25", models.Cursor{Keys: []string{"cursor a"}}.Encode()} {
+			req = httptest.NewRequest("GET", "/blog/tags?limit=1&cursor="+cursor, nil)
+			res = httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusBadRequest, res.Code, cursor)
+		}
+	})
+
 	t.Run("Create success", func(t *testing.T) {
 		input := models.BlogTag{Name: "test tag"}
 		body, _ := json.Marshal(input)
@@ -88,6 +173,41 @@ func Test_BlogTagRoutes(t *testing.T) {
 		assert.NotEmpty(t, id)
 	})
 
+	t.Run("Create failed with duplicate name", func(t *testing.T) {
+		input := models.BlogTag{Name: "test tag"}
+		body, _ := json.Marshal(input)
+
+		req := httptest.NewRequest("POST", "/blog/tags", bytes.NewBuffer(body))
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusConflict, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.CONFLICT, response.Message)
+	})
+
+	t.Run("Create failed with empty name", func(t *testing.T) {
+		input := models.BlogTag{Name: " "}
+		body, _ := json.Marshal(input)
+
+		req := httptest.NewRequest("POST", "/blog/tags", bytes.NewBuffer(body))
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.VALIDATION_FAILED, response.Message)
+		assert.Equal(t, []any{map[string]any{"field": "name", "rule": "required"}}, response.Data)
+	})
+
 	t.Run("Update success", func(t *testing.T) {
 		if id == "" {
 			t.Fatal("ID must be set before running Update test")
@@ -131,4 +251,18 @@ func Test_BlogTagRoutes(t *testing.T) {
 		assert.Equal(t, message.REMOVE_DATA_SUCCESS, response.Message)
 		assert.NotNil(t, response.Data)
 	})
+
+	t.Run("Remove failed with unknown id", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/blog/tags/"+id, nil)
+		req.AddCookie(authCookie)
+		res := httptest.NewRecorder()
+
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		var response message.Response
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, message.NOT_FOUND, response.Message)
+	})
 }
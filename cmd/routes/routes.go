@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"api-chi/cmd/services"
+	"time"
+)
+
+// Dependencies are the services shared by every route, main.go creates them
+// once and passes them to each group of routes
+type Dependencies struct {
+	Auth      *services.AuthService
+	Posts     services.PostRepository
+	Tags      services.TagRepository
+	Revisions services.RevisionRepository
+	Series    services.SeriesRepository
+
+	// Time allowed for the queries of read and write routes, zero for no limit
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
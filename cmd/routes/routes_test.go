@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"api-chi/cmd/config"
+	"api-chi/cmd/services"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testAuthService signs and checks the tokens of the route tests
+func testAuthService() *services.AuthService {
+	return services.NewAuthService(config.AuthConfig{
+		Username:   "admin",
+		Password:   "admin",
+		SecretKey:  "SECRET",
+		BcryptCost: bcrypt.MinCost,
+		PreviewTtl: time.Hour,
+	})
+}
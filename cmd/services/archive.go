@@ -0,0 +1,27 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"time"
+)
+
+// addToArchive counts count posts in the month of the archive, post is
+// added to the month when not nil. Months are added newest first, so rows
+// must come newest first too.
+func addToArchive(years []models.BlogPostArchiveYear, year int, month time.Month, count int, post *models.BlogPostArchivePost) []models.BlogPostArchiveYear {
+	if len(years) == 0 || years[len(years)-1].Year != year {
+		years = append(years, models.BlogPostArchiveYear{Year: year, Months: []models.BlogPostArchiveMonth{}})
+	}
+	last := &years[len(years)-1]
+	if len(last.Months) == 0 || last.Months[len(last.Months)-1].Month != int(month) {
+		last.Months = append(last.Months, models.BlogPostArchiveMonth{Month: int(month)})
+	}
+	lastMonth := &last.Months[len(last.Months)-1]
+
+	last.Count += count
+	lastMonth.Count += count
+	if post != nil {
+		lastMonth.Posts = append(lastMonth.Posts, *post)
+	}
+	return years
+}
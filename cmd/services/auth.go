@@ -4,17 +4,18 @@ import (
 	"api-chi/cmd/config"
 	"api-chi/cmd/models"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/bcrypt"
 )
 
-type AuthService struct{}
+type AuthService struct {
+	Config config.AuthConfig
+}
 
-func (s *AuthService) New() {
-	config.LoadAuthConfig()
+func NewAuthService(cfg config.AuthConfig) *AuthService {
+	return &AuthService{Config: cfg}
 }
 
 func (s *AuthService) GenerateToken(input *models.Auth) (string, error) {
@@ -31,7 +32,7 @@ func (s *AuthService) GenerateToken(input *models.Auth) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Retrieve the secret key from config
-	secretKey := []byte(config.AUTH_SECRET_KEY)
+	secretKey := []byte(s.Config.SecretKey)
 
 	// Sign the token with the secret key
 	tokenString, err := token.SignedString(secretKey)
@@ -44,7 +45,7 @@ func (s *AuthService) GenerateToken(input *models.Auth) (string, error) {
 
 func (s *AuthService) ValidateToken(tokenString string) (bool, error) {
 	// Retrieve the secret key from config
-	secretKey := []byte(config.AUTH_SECRET_KEY)
+	secretKey := []byte(s.Config.SecretKey)
 
 	// Parse and validate the token
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
@@ -86,26 +87,66 @@ func (s *AuthService) ValidateToken(tokenString string) (bool, error) {
 	return false, fmt.Errorf("invalid token claims")
 }
 
-func (s *AuthService) Login(input *models.Auth) error {
-	if config.AUTH_BCRYPT_COST == "" {
-		config.AUTH_BCRYPT_COST = "10"
+// GeneratePreviewToken signs a token letting anybody read one post,
+// drafts included, until it expires
+func (s *AuthService) GeneratePreviewToken(postId string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(s.Config.PreviewTtl)
+
+	// Preview tokens have no username so they can't be used to log in
+	claims := jwt.MapClaims{
+		"preview": postId,
+		"exp":     expiresAt.Unix(),
 	}
-	bcryptCost, err := strconv.Atoi(config.AUTH_BCRYPT_COST)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	tokenString, err := token.SignedString([]byte(s.Config.SecretKey))
 	if err != nil {
-		return fmt.Errorf("can't set bcrypt default cost")
+		return "", time.Time{}, err
 	}
 
+	return tokenString, time.Unix(expiresAt.Unix(), 0), nil
+}
+
+// ValidatePreviewToken returns the id of the post a preview token was made for
+func (s *AuthService) ValidatePreviewToken(tokenString string) (string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.Config.SecretKey), nil
+	})
+	if err != nil || !token.Valid {
+		return "", fmt.Errorf("invalid or expired token")
+	}
+
+	// Parse checks exp, only the post id is left to extract
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", fmt.Errorf("invalid token claims")
+	}
+	postId, ok := claims["preview"].(string)
+	if !ok || postId == "" {
+		return "", fmt.Errorf("preview claim not found")
+	}
+	if _, ok := claims["exp"].(float64); !ok {
+		return "", fmt.Errorf("token expiration not found")
+	}
+
+	return postId, nil
+}
+
+func (s *AuthService) Login(input *models.Auth) error {
 	// Check username
-	if input.Username != config.AUTH_USERNAME {
+	if input.Username != s.Config.Username {
 		return fmt.Errorf("username is incorrect")
 	}
 
 	// Check password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcryptCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), s.Config.BcryptCost)
 	if err != nil {
 		return fmt.Errorf("error hashing password: %s", err)
 	}
-	err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(config.AUTH_PASSWORD))
+	err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(s.Config.Password))
 	if err != nil {
 		return fmt.Errorf("password is incorrect")
 	}
@@ -8,11 +8,17 @@ import (
 
 	"github.com/golang-jwt/jwt"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func Test_AuthService(t *testing.T) {
-	service := AuthService{}
-	service.New()
+	service := NewAuthService(config.AuthConfig{
+		Username:   "admin",
+		Password:   "admin",
+		SecretKey:  "SECRET",
+		BcryptCost: bcrypt.MinCost,
+		PreviewTtl: time.Hour,
+	})
 
 	t.Run("Login failed", func(t *testing.T) {
 		// Login data
@@ -53,7 +59,7 @@ func Test_AuthService(t *testing.T) {
 
 		// Parse the token to verify claims
 		parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-			return []byte(config.AUTH_SECRET_KEY), nil
+			return []byte(service.Config.SecretKey), nil
 		})
 		assert.NoError(t, err)
 		assert.NotNil(t, parsedToken)
@@ -85,4 +91,34 @@ func Test_AuthService(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, valid)
 	})
+	t.Run("Preview token success", func(t *testing.T) {
+		tokenString, expiresAt, err := service.GeneratePreviewToken("post-id")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokenString)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+		postId, err := service.ValidatePreviewToken(tokenString)
+		assert.NoError(t, err)
+		assert.Equal(t, "post-id", postId)
+
+		// Preview tokens don't log in
+		valid, err := service.ValidateToken(tokenString)
+		assert.Error(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("Preview token failed", func(t *testing.T) {
+		// Login tokens don't grant previews
+		loginToken, err := service.GenerateToken(&models.Auth{Username: "admin"})
+		assert.NoError(t, err)
+		_, err = service.ValidatePreviewToken(loginToken)
+		assert.Error(t, err)
+
+		// Tokens signed with another key are rejected
+		other := NewAuthService(config.AuthConfig{SecretKey: "OTHER", PreviewTtl: time.Hour})
+		otherToken, _, err := other.GeneratePreviewToken("post-id")
+		assert.NoError(t, err)
+		_, err = service.ValidatePreviewToken(otherToken)
+		assert.Error(t, err)
+	})
 }
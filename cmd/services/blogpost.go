@@ -1,526 +1,984 @@
 package services
 
 import (
-	"api-chi/cmd/config"
 	"api-chi/cmd/models"
-	"fmt"
-	"strings"
+	"context"
+	"errors"
+	"slices"
+	"time"
 
-	"github.com/gosimple/slug"
 	"github.com/jackc/pgx/v5"
 )
 
+// publishLockId is the advisory lock held while publishing scheduled posts,
+// so that several instances don't publish the same posts at once
+const publishLockId = 5003_0002
+
 type BlogPostService struct {
-	Conn DatabaseService
+	Conn *DatabaseService
 }
 
-func (s *BlogPostService) Open() error {
-	return s.Conn.Open()
+func NewBlogPostService(conn *DatabaseService) *BlogPostService {
+	return &BlogPostService{Conn: conn}
 }
 
-func (s *BlogPostService) Close() {
-	s.Conn.Close()
+// statusCondition returns the SQL condition keeping the posts of a status,
+// an empty status keeps the published ones
+func statusCondition(status string) string {
+	switch status {
+	case models.STATUS_ALL:
+		return ""
+	case models.STATUS_DRAFT:
+		return " AND blog_post.is_draft AND blog_post.publish_at IS NULL"
+	case models.STATUS_SCHEDULED:
+		return " AND blog_post.is_draft AND blog_post.publish_at IS NOT NULL"
+	default:
+		return " AND NOT blog_post.is_draft"
+	}
 }
 
-func (s *BlogPostService) Count(search string, tags []models.BlogTag) (int, error) {
-	// Base SQL query
-	sql := "SELECT COUNT(blog_post.id) FROM blog_post "
+// postFilter returns the WHERE clause and the arguments selecting the posts
+// of filter. Count, GetAll and GetAllWithContent share it so that counts
+// always match the pages, tags are checked in subqueries rather than joins
+// so that every post stays a single row.
+func (s *BlogPostService) postFilter(filter models.BlogPostFilter) (string, pgx.NamedArgs) {
+	sql := "WHERE " + searchCondition(filter.Search) + statusCondition(filter.Status)
 	args := pgx.NamedArgs{
-		"search": search,
-	}
-
-	// Add JOINs and tag filter only if tags are provided
-	if len(tags) > 0 {
-		sql += `
-			INNER JOIN blog_post_tag ON blog_post_tag.post_id = blog_post.id
-			INNER JOIN blog_tag ON blog_post_tag.tag_id = blog_tag.id
-			WHERE blog_post.title ILIKE '%' || @search || '%'
-		`
-
-		// Add tag names to the query
-		tagNames := make([]string, len(tags))
-		for i, tag := range tags {
-			paramName := fmt.Sprintf("tag_%d", i)
-			args[paramName] = tag.Name
-			tagNames[i] = fmt.Sprintf("@%s", paramName)
+		"search":   filter.Search,
+		"language": s.Conn.SearchLanguage,
+	}
+
+	// Keep posts having all or any of the tags
+	if names := tagNames(filter.Tags); len(names) > 0 {
+		args["tags"] = names
+		if filter.TagMode == models.TAG_MODE_ANY {
+			sql += " AND EXISTS (SELECT 1 " + postTagsSql("tags") + ")"
+		} else {
+			args["tag_count"] = len(names)
+			sql += " AND (SELECT COUNT(DISTINCT blog_tag.name) " + postTagsSql("tags") + ") = @tag_count"
 		}
+	}
+
+	// Drop posts having any of the excluded tags
+	if names := tagNames(filter.ExcludeTags); len(names) > 0 {
+		args["exclude_tags"] = names
+		sql += " AND NOT EXISTS (SELECT 1 " + postTagsSql("exclude_tags") + ")"
+	}
+
+	// Keep posts created in the range
+	if filter.From != nil {
+		args["from"] = *filter.From
+		sql += " AND blog_post.created_at >= @from"
+	}
+	if filter.To != nil {
+		args["to"] = *filter.To
+		sql += " AND blog_post.created_at < @to"
+	}
+
+	return sql, args
+}
+
+// postTagsSql returns the FROM and WHERE of a subquery over the tags of the
+// current blog_post row named in the param text array
+func postTagsSql(param string) string {
+	return `
+		FROM blog_post_tag
+		INNER JOIN blog_tag ON blog_tag.id = blog_post_tag.tag_id
+		WHERE blog_post_tag.post_id = blog_post.id AND blog_tag.name = ANY(@` + param + `::text[])`
+}
 
-		// Add the tag filter to the query
-		sql += fmt.Sprintf(" AND blog_tag.name IN (%s)", strings.Join(tagNames, ", "))
-	} else {
-		sql += " WHERE blog_post.title ILIKE '%' || @search || '%'"
+// tagNames returns the distinct names of tags
+func tagNames(tags []models.BlogTag) []string {
+	value := []string{}
+	for _, tag := range tags {
+		if tag.Name != "" && !slices.Contains(value, tag.Name) {
+			value = append(value, tag.Name)
+		}
 	}
+	return value
+}
+
+func (s *BlogPostService) Count(ctx context.Context, filter models.BlogPostFilter) (int, error) {
+	where, args := s.postFilter(filter)
+	value, err := count(ctx, s.Conn, where, args)
+	return value, databaseError(err)
+}
+
+// count returns the number of posts matching where, made by postFilter
+func count(ctx context.Context, q querier, where string, args pgx.NamedArgs) (int, error) {
+	sql := "SELECT COUNT(*) FROM blog_post " + where + ";"
 
 	value := 0
-	err := s.Conn.QueryRow(config.CTX, sql, args).Scan(&value)
-	if err != nil {
-		return value, err
+	err := q.QueryRow(ctx, sql, args).Scan(&value)
+	return value, err
+}
+
+func (s *BlogPostService) GetWithSlug(ctx context.Context, slug string, status string) (models.BlogPostContentWithTags, error) {
+	value, err := s.getPost(ctx, "slug = @slug"+statusCondition(status), pgx.NamedArgs{"slug": slug})
+	if err == nil {
+		// Place the post among the parts of its series the caller may see
+		value.Series, err = postSeries(ctx, s.Conn, value.Id, status)
+		return value, databaseError(err)
 	}
+	return value, s.movedError(ctx, slug, status, err)
+}
 
-	// If success return nil
-	return value, nil
+// movedError returns a MovedError when slug is an old slug of a post with
+// the status, err is the one of the lookup with the current slugs
+func (s *BlogPostService) movedError(ctx context.Context, slug string, status string, err error) error {
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return databaseError(err)
+	}
+
+	// Old slugs point at the current one of the same post
+	movedSql := `
+		SELECT blog_post.slug
+		FROM blog_post_slug
+		INNER JOIN blog_post ON blog_post.id = blog_post_slug.post_id
+		WHERE blog_post_slug.slug = @slug` + statusCondition(status) + ";"
+	current := ""
+	if movedErr := s.Conn.QueryRow(ctx, movedSql, pgx.NamedArgs{"slug": slug}).Scan(&current); movedErr != nil {
+		if errors.Is(movedErr, pgx.ErrNoRows) {
+			return databaseError(err)
+		}
+		return databaseError(movedErr)
+	}
+	return &MovedError{Slug: current}
 }
 
-func (s *BlogPostService) GetWithSlug(slug string) (models.BlogPostContentWithTags, error) {
+// GetWithId returns a post whatever its status, for preview links
+func (s *BlogPostService) GetWithId(ctx context.Context, id string) (models.BlogPostContentWithTags, error) {
+	value, err := s.getPost(ctx, "id = @id", pgx.NamedArgs{"id": id})
+	return value, databaseError(err)
+}
+
+// getPost returns the post matching the condition with its tags
+func (s *BlogPostService) getPost(ctx context.Context, condition string, args pgx.NamedArgs) (models.BlogPostContentWithTags, error) {
 	// post SQL query
 	postSql := `
 		SELECT
 			id,
 			title,
 			slug,
+			summary,
+			excerpt,
+			word_count,
+			reading_time_minutes,
 			content,
 			created_at,
 			updated_at,
-			is_draft
+			is_draft,
+			publish_at
 		FROM blog_post
-		WHERE slug = @slug;
-	`
-
-	// Add tag filters if tags are provided
-	args := pgx.NamedArgs{
-		"slug": slug,
-	}
+		WHERE ` + condition + ";"
 
 	// Execute post sql
 	value := models.BlogPostContentWithTags{}
-	err := s.Conn.QueryRow(config.CTX, postSql, args).Scan(
+	err := s.Conn.QueryRow(ctx, postSql, args).Scan(
 		&value.Id,
 		&value.Title,
 		&value.Slug,
+		&value.Summary,
+		&value.Excerpt,
+		&value.WordCount,
+		&value.ReadingTimeMinutes,
 		&value.Content,
 		&value.CreatedAt,
 		&value.UpdatedAt,
 		&value.IsDraft,
+		&value.PublishAt,
 	)
 	if err != nil {
 		return value, err
 	}
 
-	// Get tags from sql using parameterized query
-	tagSql := `
-			SELECT blog_tag.id, blog_tag.name
-			FROM blog_tag
-			INNER JOIN blog_post_tag ON blog_post_tag.tag_id = blog_tag.id
-			WHERE blog_post_tag.post_id = $1;`
-	tagRows, err := s.Conn.Query(config.CTX, tagSql, value.Id)
+	// Get tags of the post
+	tags, err := getTags(ctx, s.Conn, []string{value.Id})
 	if err != nil {
 		return value, err
 	}
-
-	for tagRows.Next() {
-		tagItem := models.BlogTag{}
-		if err := tagRows.Scan(
-			&tagItem.Id,
-			&tagItem.Name,
-		); err != nil {
-			return value, err
-		}
-		value.Tags = append(value.Tags, tagItem)
-	}
+	value.Tags = tags[value.Id]
 
 	return value, nil
 }
 
-func (s *BlogPostService) GetAll(search string, tags []models.BlogTag, limit int, page int) ([]models.BlogPostWithTags, error) {
-	// Set default range for limit
+// postLimit returns limit in the range of the post listings
+func postLimit(limit int) int {
 	if limit < 10 {
-		limit = 10
+		return 10
 	} else if limit > 50 {
-		limit = 50
+		return 50
 	}
+	return limit
+}
 
-	// Set default range for page
-	if page < 1 {
-		page = 0
-	} else {
-		page -= 1
-	}
+// listArgs adds the arguments shared by GetAll and GetAllWithContent to
+// args and returns the sort and pagination clauses, limit and page are the
+// ones of postLimit and pageNumber
+func listArgs(args pgx.NamedArgs, filter models.BlogPostFilter, limit int, page int) string {
+	args["headline_options"] = headlineOptions
+	args["limit"] = limit
+	args["page"] = (page - 1) * limit
+
+	sort := postSort(filter.Sort, filter.Search)
+	return orderBy(sort, postSortColumns, "blog_post.id") + " LIMIT @limit OFFSET @page;"
+}
+
+func (s *BlogPostService) GetAll(ctx context.Context, filter models.BlogPostFilter, limit int, page int) ([]models.BlogPostWithTags, models.PageMeta, error) {
+	// Set default range for limit and page
+	limit, page = postLimit(limit), pageNumber(page)
 
 	// post SQL query
+	where, args := s.postFilter(filter)
 	postSql := `
 		SELECT
 			blog_post.id,
 			blog_post.title,
 			blog_post.slug,
+			blog_post.excerpt,
+			blog_post.word_count,
+			blog_post.reading_time_minutes,
 			blog_post.created_at,
 			blog_post.updated_at,
-			blog_post.is_draft
+			blog_post.is_draft,
+			blog_post.publish_at,
+			CASE WHEN @search = '' THEN '' ELSE ts_headline(@language::regconfig, blog_post.content, query, @headline_options) END
 		FROM blog_post
-	`
+		CROSS JOIN websearch_to_tsquery(@language::regconfig, @search) AS query
+	` + where + listArgs(args, filter, limit, page)
 
-	// Add tag filters if tags are provided
-	args := pgx.NamedArgs{
-		"search": search,
-		"limit":  limit,
-		"page":   page * limit,
-	}
-
-	if len(tags) > 0 {
-		postSql += `
-			INNER JOIN blog_post_tag ON blog_post_tag.post_id = blog_post.id
-			INNER JOIN blog_tag ON blog_post_tag.tag_id = blog_tag.id
-			WHERE blog_post.title ILIKE '%' || @search || '%'
-		`
-
-		// Add tag names to the query
-		tagNames := make([]string, len(tags))
-		for i, tag := range tags {
-			paramName := fmt.Sprintf("tag_%d", i)
-			args[paramName] = tag.Name
-			tagNames[i] = fmt.Sprintf("@%s", paramName)
+	// The total and the page are read from the same snapshot
+	value := []models.BlogPostWithTags{}
+	meta := models.PageMeta{}
+	err := s.Conn.readSnapshot(ctx, func(tx pgx.Tx) error {
+		total, err := count(ctx, tx, where, args)
+		if err != nil {
+			return err
+		}
+		meta = newPageMeta(total, limit, page)
+
+		// Execute post sql
+		rows, err := tx.Query(ctx, postSql, args)
+		if err != nil {
+			return err
 		}
+		defer rows.Close()
+
+		postIds := []string{}
+		for rows.Next() {
+			postItem := models.BlogPostWithTags{}
+
+			// Scan post
+			if err := rows.Scan(
+				&postItem.Id,
+				&postItem.Title,
+				&postItem.Slug,
+				&postItem.Excerpt,
+				&postItem.WordCount,
+				&postItem.ReadingTimeMinutes,
+				&postItem.CreatedAt,
+				&postItem.UpdatedAt,
+				&postItem.IsDraft,
+				&postItem.PublishAt,
+				&postItem.Snippet,
+			); err != nil {
+				return err
+			}
+			postItem.Snippet = markSnippet(postItem.Snippet)
 
-		// Add the tag filter to the query
-		postSql += fmt.Sprintf(" AND blog_tag.name IN (%s)", strings.Join(tagNames, ", "))
+			value = append(value, postItem)
+			postIds = append(postIds, postItem.Id)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
 
-		// Group by blog post ID
-		postSql += " GROUP BY blog_post.id"
-		
-		// Add HAVING clause to ensure all specified tags are matched
-		// This ensures the blog post has ALL of the requested tags, not just any of them
-		postSql += fmt.Sprintf(" HAVING COUNT(DISTINCT blog_tag.name) >= %d", len(tags))
-	} else {
-		postSql += "WHERE blog_post.title ILIKE '%' || @search || '%'"
+		// Get tags of every post in the page at once
+		postTags, err := getTags(ctx, tx, postIds)
+		if err != nil {
+			return err
+		}
+		for i := range value {
+			value[i].Tags = postTags[value[i].Id]
+		}
+		return nil
+	})
+
+	return value, meta, databaseError(err)
+}
+
+// GetAllWithCursor returns the page of posts after or before the one
+// cursor points at, the first page when cursor is nil, with the cursors of
+// the pages around it. The sort of the cursor wins over the one of filter.
+func (s *BlogPostService) GetAllWithCursor(ctx context.Context, filter models.BlogPostFilter, cursor *models.Cursor, limit int) ([]models.BlogPostWithTags, models.Cursors, error) {
+	// Keep the rows after the cursor
+	where, args := s.postFilter(filter)
+	sort := postSort(filter.Sort, filter.Search)
+	if cursor != nil {
+		sort = postSort(cursor.Sort, filter.Search)
+		condition, err := keysetCondition(*cursor, sort, postSortColumns, "blog_post.id", args)
+		if err != nil {
+			return nil, models.Cursors{}, err
+		}
+		where += " AND " + condition
 	}
 
-	// Add pagination
-	postSql += " LIMIT @limit OFFSET @page;"
+	// One more row tells whether there is a page after this one
+	limit = postLimit(limit)
+	args["headline_options"] = headlineOptions
+	args["limit"] = limit + 1
+
+	// post SQL query
+	postSql := `
+		SELECT
+			blog_post.id,
+			blog_post.title,
+			blog_post.slug,
+			blog_post.excerpt,
+			blog_post.word_count,
+			blog_post.reading_time_minutes,
+			blog_post.created_at,
+			blog_post.updated_at,
+			blog_post.is_draft,
+			blog_post.publish_at,
+			CASE WHEN @search = '' THEN '' ELSE ts_headline(@language::regconfig, blog_post.content, query, @headline_options) END,
+			` + cursorKeysSql(sort, postSortColumns, "blog_post.id") + `
+		FROM blog_post
+		CROSS JOIN websearch_to_tsquery(@language::regconfig, @search) AS query
+	` + where + orderBy(pageSort(sort, cursor), postSortColumns, "blog_post.id") + " LIMIT @limit;"
 
 	// Execute post sql
 	value := []models.BlogPostWithTags{}
-	rows, err := s.Conn.Query(config.CTX, postSql, args)
+	keys := [][]string{}
+	rows, err := s.Conn.Query(ctx, postSql, args)
 	if err != nil {
-		return value, err
+		return value, models.Cursors{}, cursorError(err)
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		postItem := models.BlogPostWithTags{}
+		postKeys := []string{}
 
 		// Scan post
 		if err := rows.Scan(
 			&postItem.Id,
 			&postItem.Title,
 			&postItem.Slug,
+			&postItem.Excerpt,
+			&postItem.WordCount,
+			&postItem.ReadingTimeMinutes,
 			&postItem.CreatedAt,
 			&postItem.UpdatedAt,
 			&postItem.IsDraft,
+			&postItem.PublishAt,
+			&postItem.Snippet,
+			&postKeys,
 		); err != nil {
-			return value, err
-		}
-
-		// Get tags from sql using parameterized query
-		tagSql := `
-			SELECT blog_tag.id, blog_tag.name
-			FROM blog_tag
-			INNER JOIN blog_post_tag ON blog_post_tag.tag_id = blog_tag.id
-			WHERE blog_post_tag.post_id = $1;`
-		tagRows, err := s.Conn.Query(config.CTX, tagSql, postItem.Id)
-		if err != nil {
-			return value, err
-		}
-
-		for tagRows.Next() {
-			tagItem := models.BlogTag{}
-			if err := tagRows.Scan(
-				&tagItem.Id,
-				&tagItem.Name,
-			); err != nil {
-				return value, err
-			}
-			postItem.Tags = append(postItem.Tags, tagItem)
+			return value, models.Cursors{}, cursorError(err)
 		}
+		postItem.Snippet = markSnippet(postItem.Snippet)
 
 		value = append(value, postItem)
+		keys = append(keys, postKeys)
 	}
-
-	return value, nil
-}
-
-func (s *BlogPostService) GetAllWithContent(search string, tags []models.BlogTag, limit int, page int) ([]models.BlogPostContentWithTags, error) {
-	// Set default range for limit
-	if limit < 10 {
-		limit = 10
-	} else if limit > 50 {
-		limit = 50
+	if err := rows.Err(); err != nil {
+		return value, models.Cursors{}, cursorError(err)
 	}
+	value, cursors := cursorPage(value, keys, sort, cursor, limit)
 
-	// Set default range for page
-	if page < 1 {
-		page = 0
-	} else {
-		page -= 1
+	// Get tags of every post in the page at once
+	postIds := []string{}
+	for _, post := range value {
+		postIds = append(postIds, post.Id)
+	}
+	postTags, err := getTags(ctx, s.Conn, postIds)
+	if err != nil {
+		return value, cursors, databaseError(err)
 	}
+	for i := range value {
+		value[i].Tags = postTags[value[i].Id]
+	}
+
+	return value, cursors, nil
+}
 
+func (s *BlogPostService) GetAllWithContent(ctx context.Context, filter models.BlogPostFilter, limit int, page int) ([]models.BlogPostContentWithTags, models.PageMeta, error) {
+	// Set default range for limit and page
+	limit, page = postLimit(limit), pageNumber(page)
 
 	// post SQL query
+	where, args := s.postFilter(filter)
 	postSql := `
 		SELECT
 			blog_post.id,
 			blog_post.title,
 			blog_post.slug,
+			blog_post.summary,
+			blog_post.excerpt,
+			blog_post.word_count,
+			blog_post.reading_time_minutes,
 			blog_post.content,
 			blog_post.created_at,
 			blog_post.updated_at,
-			blog_post.is_draft
+			blog_post.is_draft,
+			blog_post.publish_at,
+			CASE WHEN @search = '' THEN '' ELSE ts_headline(@language::regconfig, blog_post.content, query, @headline_options) END
 		FROM blog_post
-	`
+		CROSS JOIN websearch_to_tsquery(@language::regconfig, @search) AS query
+	` + where + listArgs(args, filter, limit, page)
 
-	// Add tag filters if tags are provided
-	args := pgx.NamedArgs{
-		"search": search,
-		"limit":  limit,
-		"page":   page * limit,
-	}
-
-	if len(tags) > 0 {
-		postSql += `
-			INNER JOIN blog_post_tag ON blog_post_tag.post_id = blog_post.id
-			INNER JOIN blog_tag ON blog_post_tag.tag_id = blog_tag.id
-			WHERE blog_post.title ILIKE '%' || @search || '%'
-		`
-
-		// Add tag names to the query
-		tagNames := make([]string, len(tags))
-		for i, tag := range tags {
-			paramName := fmt.Sprintf("tag_%d", i)
-			args[paramName] = tag.Name
-			tagNames[i] = fmt.Sprintf("@%s", paramName)
+	// The total and the page are read from the same snapshot
+	value := []models.BlogPostContentWithTags{}
+	meta := models.PageMeta{}
+	err := s.Conn.readSnapshot(ctx, func(tx pgx.Tx) error {
+		total, err := count(ctx, tx, where, args)
+		if err != nil {
+			return err
 		}
+		meta = newPageMeta(total, limit, page)
 
-		// Add the tag filter to the query
-		postSql += fmt.Sprintf(" AND blog_tag.name IN (%s)", strings.Join(tagNames, ", "))
+		// Execute post sql
+		rows, err := tx.Query(ctx, postSql, args)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		postIds := []string{}
+		for rows.Next() {
+			postItem := models.BlogPostContentWithTags{}
+
+			// Scan post
+			if err := rows.Scan(
+				&postItem.Id,
+				&postItem.Title,
+				&postItem.Slug,
+				&postItem.Summary,
+				&postItem.Excerpt,
+				&postItem.WordCount,
+				&postItem.ReadingTimeMinutes,
+				&postItem.Content,
+				&postItem.CreatedAt,
+				&postItem.UpdatedAt,
+				&postItem.IsDraft,
+				&postItem.PublishAt,
+				&postItem.Snippet,
+			); err != nil {
+				return err
+			}
+			postItem.Snippet = markSnippet(postItem.Snippet)
 
-		// Group by blog post ID
-		postSql += " GROUP BY blog_post.id"
-		
-		// Add HAVING clause to ensure all specified tags are matched
-		// This ensures the blog post has ALL of the requested tags, not just any of them
-		postSql += fmt.Sprintf(" HAVING COUNT(DISTINCT blog_tag.name) >= %d", len(tags))
-	} else {
-		postSql += "WHERE blog_post.title ILIKE '%' || @search || '%'"
-	}
+			value = append(value, postItem)
+			postIds = append(postIds, postItem.Id)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
 
-	// Add pagination
-	postSql += " LIMIT @limit OFFSET @page;"
+		// Get tags of every post in the page at once
+		postTags, err := getTags(ctx, tx, postIds)
+		if err != nil {
+			return err
+		}
+		for i := range value {
+			value[i].Tags = postTags[value[i].Id]
+		}
+		return nil
+	})
 
-	// Execute post sql
-	value := []models.BlogPostContentWithTags{}
-	rows, err := s.Conn.Query(config.CTX, postSql, args)
+	return value, meta, databaseError(err)
+}
+
+// GetArchive returns the posts of filter counted by year and month, with
+// their titles and slugs when withPosts is set
+func (s *BlogPostService) GetArchive(ctx context.Context, filter models.BlogPostFilter, withPosts bool) ([]models.BlogPostArchiveYear, error) {
+	where, args := s.postFilter(filter)
+	value := []models.BlogPostArchiveYear{}
+
+	// Only counts are needed, they are made by Postgres
+	if !withPosts {
+		sql := `
+			SELECT
+				EXTRACT(YEAR FROM blog_post.created_at AT TIME ZONE 'UTC')::int,
+				EXTRACT(MONTH FROM blog_post.created_at AT TIME ZONE 'UTC')::int,
+				COUNT(*)
+			FROM blog_post
+		` + where + " GROUP BY 1, 2 ORDER BY 1 DESC, 2 DESC;"
+		rows, err := s.Conn.Query(ctx, sql, args)
+		if err != nil {
+			return value, databaseError(err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			year, month, count := 0, 0, 0
+			if err := rows.Scan(&year, &month, &count); err != nil {
+				return value, databaseError(err)
+			}
+			value = addToArchive(value, year, time.Month(month), count, nil)
+		}
+		return value, databaseError(rows.Err())
+	}
+
+	// Every post is listed, newest first
+	sql := "SELECT blog_post.id, blog_post.title, blog_post.slug, blog_post.created_at FROM blog_post " + where
+	sql += " ORDER BY blog_post.created_at DESC, blog_post.id DESC;"
+	rows, err := s.Conn.Query(ctx, sql, args)
 	if err != nil {
-		return value, err
+		return value, databaseError(err)
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		postItem := models.BlogPostContentWithTags{}
+		post := models.BlogPostArchivePost{}
+		if err := rows.Scan(&post.Id, &post.Title, &post.Slug, &post.CreatedAt); err != nil {
+			return value, databaseError(err)
+		}
+		createdAt := post.CreatedAt.UTC()
+		value = addToArchive(value, createdAt.Year(), createdAt.Month(), 1, &post)
+	}
+	return value, databaseError(rows.Err())
+}
 
-		// Scan post
+// GetRelated returns the published posts sharing tags with the post of slug
+// having the status. Each shared tag counts relatedWeight of the number of
+// published posts having it, the highest scores come first and the newest
+// posts win ties.
+func (s *BlogPostService) GetRelated(ctx context.Context, slug string, status string, limit int) ([]models.BlogPostWithTags, error) {
+	value := []models.BlogPostWithTags{}
+	postId := ""
+	err := s.Conn.QueryRow(ctx, "SELECT blog_post.id FROM blog_post WHERE slug = @slug"+statusCondition(status)+";", pgx.NamedArgs{"slug": slug}).Scan(&postId)
+	if err != nil {
+		return value, s.movedError(ctx, slug, status, err)
+	}
+
+	// Score the other published posts on the tags they share with the post
+	sql := `
+		WITH usage AS (
+			SELECT blog_post_tag.tag_id, COUNT(*) AS posts
+			FROM blog_post_tag
+			INNER JOIN blog_post ON blog_post.id = blog_post_tag.post_id
+			WHERE NOT blog_post.is_draft
+			GROUP BY blog_post_tag.tag_id
+		), related AS (
+			SELECT other.post_id, ROUND(SUM(1 / LN(1 + usage.posts))::numeric, @digits) AS score
+			FROM blog_post_tag AS source
+			INNER JOIN blog_post_tag AS other ON other.tag_id = source.tag_id AND other.post_id <> source.post_id
+			INNER JOIN usage ON usage.tag_id = source.tag_id
+			WHERE source.post_id = @post_id
+			GROUP BY other.post_id
+		)
+		SELECT
+			blog_post.id,
+			blog_post.title,
+			blog_post.slug,
+			blog_post.excerpt,
+			blog_post.word_count,
+			blog_post.reading_time_minutes,
+			blog_post.created_at,
+			blog_post.updated_at,
+			blog_post.is_draft,
+			blog_post.publish_at
+		FROM related
+		INNER JOIN blog_post ON blog_post.id = related.post_id
+		WHERE NOT blog_post.is_draft
+		ORDER BY related.score DESC, blog_post.created_at DESC, blog_post.id DESC
+		LIMIT @limit;
+	`
+	args := pgx.NamedArgs{
+		"post_id": postId,
+		"digits":  relatedScoreDigits,
+		"limit":   relatedLimit(limit),
+	}
+	rows, err := s.Conn.Query(ctx, sql, args)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	defer rows.Close()
+
+	postIds := []string{}
+	for rows.Next() {
+		postItem := models.BlogPostWithTags{}
 		if err := rows.Scan(
 			&postItem.Id,
 			&postItem.Title,
 			&postItem.Slug,
-			&postItem.Content,
+			&postItem.Excerpt,
+			&postItem.WordCount,
+			&postItem.ReadingTimeMinutes,
 			&postItem.CreatedAt,
 			&postItem.UpdatedAt,
 			&postItem.IsDraft,
+			&postItem.PublishAt,
 		); err != nil {
-			return value, err
+			return value, databaseError(err)
 		}
+		value = append(value, postItem)
+		postIds = append(postIds, postItem.Id)
+	}
+	if err := rows.Err(); err != nil {
+		return value, databaseError(err)
+	}
 
-		// Get tags from sql using parameterized query
-		tagSql := `
-			SELECT blog_tag.id, blog_tag.name
-			FROM blog_tag
-			INNER JOIN blog_post_tag ON blog_post_tag.tag_id = blog_tag.id
-			WHERE blog_post_tag.post_id = $1;`
-		tagRows, err := s.Conn.Query(config.CTX, tagSql, postItem.Id)
-		if err != nil {
-			return value, err
-		}
+	// Get tags of every related post at once
+	postTags, err := getTags(ctx, s.Conn, postIds)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	for i := range value {
+		value[i].Tags = postTags[value[i].Id]
+	}
+	return value, nil
+}
 
-		for tagRows.Next() {
-			tagItem := models.BlogTag{}
-			if err := tagRows.Scan(
-				&tagItem.Id,
-				&tagItem.Name,
-			); err != nil {
-				return value, err
-			}
-			postItem.Tags = append(postItem.Tags, tagItem)
-		}
+// getTags returns the tags of every post in postIds, keyed by post id,
+// with a single query no matter how many posts are asked for
+func getTags(ctx context.Context, q querier, postIds []string) (map[string][]models.BlogTag, error) {
+	tagSql := `
+		SELECT blog_post_tag.post_id, blog_tag.id, blog_tag.name
+		FROM blog_tag
+		INNER JOIN blog_post_tag ON blog_post_tag.tag_id = blog_tag.id
+		WHERE blog_post_tag.post_id = ANY(@post_ids);
+	`
+	value := map[string][]models.BlogTag{}
+	if len(postIds) == 0 {
+		return value, nil
+	}
 
-		value = append(value, postItem)
+	tagRows, err := q.Query(ctx, tagSql, pgx.NamedArgs{"post_ids": postIds})
+	if err != nil {
+		return value, err
 	}
+	defer tagRows.Close()
 
-	return value, nil
+	for tagRows.Next() {
+		postId := ""
+		tagItem := models.BlogTag{}
+		if err := tagRows.Scan(&postId, &tagItem.Id, &tagItem.Name); err != nil {
+			return value, err
+		}
+		value[postId] = append(value[postId], tagItem)
+	}
+
+	return value, tagRows.Err()
 }
 
-func (s *BlogPostService) Create(input *models.BlogPostCreated) (models.BlogPostContentWithTags, error) {
+func (s *BlogPostService) Create(ctx context.Context, input *models.BlogPostCreated) (models.BlogPostContentWithTags, error) {
+	// Post and its tags are written in one transaction
+	value := models.BlogPostContentWithTags{}
+	tx, err := s.Conn.Begin(ctx)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	// Get slug string
-	slugString := slug.Make(input.Title)
+	slugString, err := chooseSlug(ctx, tx, input.Title, input.Slug, "")
+	if err != nil {
+		return value, databaseError(err)
+	}
 
-	// Create post
+	// Create post with the stats of its content
+	stats := postStats(input.Summary, input.Content)
 	postSql := `
-		INSERT INTO blog_post (title, slug, content, created_at, updated_at, is_draft)
-	 	VALUES (@title, @slug, @content, @created_at, @updated_at, @is_draft)
-		RETURNING *;
+		INSERT INTO blog_post (title, slug, summary, excerpt, word_count, reading_time_minutes, content, created_at, updated_at, is_draft, publish_at, search_vector, search_language)
+	 	VALUES (@title, @slug, @summary, @excerpt, @word_count, @reading_time_minutes, @content, @created_at, @updated_at, @is_draft, @publish_at, ` + searchVector("@title", "@content") + `, @language)
+		RETURNING id, title, slug, summary, excerpt, word_count, reading_time_minutes, content, created_at, updated_at, is_draft, publish_at;
 	`
 	postArgs := pgx.NamedArgs{
-		"title":      input.Title,
-		"slug":       slugString,
-		"content":    input.Content,
-		"created_at": input.CreatedAt,
-		"updated_at": input.UpdatedAt,
-		"is_draft":   input.IsDraft,
-	}
-	value := models.BlogPostContentWithTags{}
-	err := s.Conn.QueryRow(config.CTX, postSql, postArgs).Scan(
+		"title":                input.Title,
+		"slug":                 slugString,
+		"summary":              input.Summary,
+		"excerpt":              stats.Excerpt,
+		"word_count":           stats.WordCount,
+		"reading_time_minutes": stats.ReadingTimeMinutes,
+		"content":              input.Content,
+		"created_at":           input.CreatedAt,
+		"updated_at":           input.UpdatedAt,
+		"is_draft":             input.IsDraft,
+		"publish_at":           input.PublishAt,
+		"language":             s.Conn.SearchLanguage,
+	}
+	err = tx.QueryRow(ctx, postSql, postArgs).Scan(
 		&value.Id,
 		&value.Title,
 		&value.Slug,
+		&value.Summary,
+		&value.Excerpt,
+		&value.WordCount,
+		&value.ReadingTimeMinutes,
 		&value.Content,
 		&value.CreatedAt,
 		&value.UpdatedAt,
 		&value.IsDraft,
+		&value.PublishAt,
 	)
 	if err != nil {
-		return value, err
+		return value, databaseError(err)
 	}
 
-	// Delete tags and create new tags for post
-	dropPostTagSql := "DELETE FROM blog_post_tag WHERE post_id = @post_id;"
-	_, err = s.Conn.Exec(config.CTX, dropPostTagSql, pgx.NamedArgs{"post_id": value.Id})
-	if err != nil {
-		return value, err
+	// The new post takes the slug over from any post that used to have it
+	if err := setSlugHistory(ctx, tx, value.Id, "", value.Slug); err != nil {
+		return value, databaseError(err)
 	}
 
-	for _, item := range input.Tags {
-		postTagSql := "INSERT INTO blog_post_tag (tag_id, post_id) VALUES (@tag_id, @post_id);"
-		postTagArgs := pgx.NamedArgs{
-			"tag_id":  item.Id,
-			"post_id": value.Id,
-		}
-		_, err := s.Conn.Exec(config.CTX, postTagSql, postTagArgs)
-		if err != nil {
-			return value, err
-		}
+	// Create tags for post
+	if err := s.setTags(ctx, tx, value.Id, input.Tags); err != nil {
+		return value, databaseError(err)
 	}
 
-	// Query tags data and append to value.Tags
-	tagSql := `
-		SELECT blog_tag.id, blog_tag.name
-		FROM blog_tag
-		INNER JOIN blog_post_tag ON blog_post_tag.tag_id = blog_tag.id
-		WHERE blog_post_tag.post_id = @post_id;
-	`
-	tagRows, err := s.Conn.Query(config.CTX, tagSql, pgx.NamedArgs{"post_id": value.Id})
-	if err != nil {
-		return value, err
+	if err := tx.Commit(ctx); err != nil {
+		return value, databaseError(err)
 	}
 
-	for tagRows.Next() {
-		tagItem := models.BlogTag{}
-		if err := tagRows.Scan(&tagItem.Id, &tagItem.Name); err != nil {
-			return value, err
-		}
-		value.Tags = append(value.Tags, tagItem)
+	// Query tags data and append to value.Tags
+	tags, err := getTags(ctx, s.Conn, []string{value.Id})
+	if err != nil {
+		return value, databaseError(err)
 	}
+	value.Tags = tags[value.Id]
 
 	// If success return nil
 	return value, nil
 }
 
-func (s *BlogPostService) Update(input *models.BlogPostUpdated) (models.BlogPostContentWithTags, error) {
-	// Get slug string
-	slugString := slug.Make(input.Title)
+func (s *BlogPostService) Update(ctx context.Context, input *models.BlogPostUpdated) (models.BlogPostContentWithTags, error) {
+	// Post and its tags are written in one transaction
+	value := models.BlogPostContentWithTags{}
+	tx, err := s.Conn.Begin(ctx)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Keep the previous title and content
+	if err := createRevision(ctx, tx, input.Id); err != nil {
+		return value, databaseError(err)
+	}
 
-	// Update post
+	// Get slug string, keeping the current one when it is custom or still
+	// fits the title
+	previousSlug, previousTitle := "", ""
+	err = tx.QueryRow(ctx, "SELECT slug, title FROM blog_post WHERE id=@id;", pgx.NamedArgs{"id": input.Id}).Scan(&previousSlug, &previousTitle)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	slugString, err := chooseSlug(ctx, tx, input.Title, updateSlug(input.Slug, previousSlug, previousTitle), previousSlug)
+	if err != nil {
+		return value, databaseError(err)
+	}
+
+	// Update post with the stats of its content
+	stats := postStats(input.Summary, input.Content)
 	sql := `
 		UPDATE blog_post SET
 			title=@title,
 			slug=@slug,
+			summary=@summary,
+			excerpt=@excerpt,
+			word_count=@word_count,
+			reading_time_minutes=@reading_time_minutes,
 			content=@content,
 			created_at=@created_at,
 			updated_at=@updated_at,
-			is_draft=@is_draft
+			is_draft=@is_draft,
+			publish_at=@publish_at,
+			search_vector=` + searchVector("@title", "@content") + `,
+			search_language=@language
 		WHERE id=@id
-		RETURNING *;
+		RETURNING id, title, slug, summary, excerpt, word_count, reading_time_minutes, content, created_at, updated_at, is_draft, publish_at;
 	`
 	args := pgx.NamedArgs{
-		"id":         input.Id,
-		"title":      input.Title,
-		"slug":       slugString,
-		"content":    input.Content,
-		"created_at": input.CreatedAt,
-		"updated_at": input.UpdatedAt,
-		"is_draft":   input.IsDraft,
-	}
-	value := models.BlogPostContentWithTags{}
-	err := s.Conn.QueryRow(config.CTX, sql, args).Scan(
+		"id":                   input.Id,
+		"title":                input.Title,
+		"slug":                 slugString,
+		"summary":              input.Summary,
+		"excerpt":              stats.Excerpt,
+		"word_count":           stats.WordCount,
+		"reading_time_minutes": stats.ReadingTimeMinutes,
+		"content":              input.Content,
+		"created_at":           input.CreatedAt,
+		"updated_at":           input.UpdatedAt,
+		"is_draft":             input.IsDraft,
+		"publish_at":           input.PublishAt,
+		"language":             s.Conn.SearchLanguage,
+	}
+	err = tx.QueryRow(ctx, sql, args).Scan(
 		&value.Id,
 		&value.Title,
 		&value.Slug,
+		&value.Summary,
+		&value.Excerpt,
+		&value.WordCount,
+		&value.ReadingTimeMinutes,
 		&value.Content,
 		&value.CreatedAt,
 		&value.UpdatedAt,
 		&value.IsDraft,
+		&value.PublishAt,
 	)
 	if err != nil {
-		return value, err
+		return value, databaseError(err)
+	}
+
+	// Remember the previous slug so that old links keep working
+	if err := setSlugHistory(ctx, tx, value.Id, previousSlug, value.Slug); err != nil {
+		return value, databaseError(err)
+	}
+
+	// Replace tags of post
+	if err := s.setTags(ctx, tx, value.Id, input.Tags); err != nil {
+		return value, databaseError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return value, databaseError(err)
+	}
+
+	// Query tags data and append to value.Tags
+	tags, err := getTags(ctx, s.Conn, []string{value.Id})
+	if err != nil {
+		return value, databaseError(err)
+	}
+	value.Tags = tags[value.Id]
+
+	// If success return nil
+	return value, nil
+}
+
+// setTags replaces the tags of a post inside tx. When a tag id doesn't exist
+// nothing is written and an InvalidTagError names the first such id.
+func (s *BlogPostService) setTags(ctx context.Context, tx pgx.Tx, postId string, tags []models.BlogTag) error {
+	tagIds := make([]string, len(tags))
+	for i, tag := range tags {
+		tagIds[i] = tag.Id
+	}
+
+	// Find the first tag id without a tag, comparing as text so that
+	// malformed ids are reported the same way as unknown ones
+	missingSql := `
+		SELECT input.id
+		FROM UNNEST(@tag_ids::text[]) WITH ORDINALITY AS input(id, position)
+		LEFT JOIN blog_tag ON blog_tag.id::text = LOWER(input.id)
+		WHERE blog_tag.id IS NULL
+		ORDER BY input.position
+		LIMIT 1;
+	`
+	missingId := ""
+	err := tx.QueryRow(ctx, missingSql, pgx.NamedArgs{"tag_ids": tagIds}).Scan(&missingId)
+	if err == nil {
+		return &InvalidTagError{TagId: missingId}
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
 	}
 
 	// Delete tags and create new tags for post
 	dropPostTagSql := "DELETE FROM blog_post_tag WHERE post_id = @post_id;"
-	_, err = s.Conn.Exec(config.CTX, dropPostTagSql, pgx.NamedArgs{"post_id": value.Id})
+	_, err = tx.Exec(ctx, dropPostTagSql, pgx.NamedArgs{"post_id": postId})
 	if err != nil {
-		return value, err
+		return err
 	}
 
-	for _, item := range input.Tags {
-		postTagSql := "INSERT INTO blog_post_tag (tag_id, post_id) VALUES (@tag_id, @post_id);"
-		postTagArgs := pgx.NamedArgs{
-			"tag_id":  item.Id,
-			"post_id": value.Id,
-		}
-		_, err := s.Conn.Exec(config.CTX, postTagSql, postTagArgs)
-		if err != nil {
-			return value, err
-		}
+	postTagSql := `
+		INSERT INTO blog_post_tag (tag_id, post_id)
+		SELECT DISTINCT tag_id::uuid, @post_id::uuid FROM UNNEST(@tag_ids::text[]) AS tag_id;
+	`
+	_, err = tx.Exec(ctx, postTagSql, pgx.NamedArgs{"tag_ids": tagIds, "post_id": postId})
+	return err
+}
+
+// setSlugHistory records that a post moved from oldSlug to newSlug inside
+// tx. A slug in use is never kept in the history, so newSlug is taken off
+// whichever post it was an old slug of. oldSlug may be empty for new posts.
+func setSlugHistory(ctx context.Context, tx pgx.Tx, postId string, oldSlug string, newSlug string) error {
+	dropSql := "DELETE FROM blog_post_slug WHERE slug = @slug;"
+	if _, err := tx.Exec(ctx, dropSql, pgx.NamedArgs{"slug": newSlug}); err != nil {
+		return err
+	}
+	if oldSlug == "" || oldSlug == newSlug {
+		return nil
 	}
 
-	// Query tags data and append to value.Tags
-	tagSql := `
-		SELECT blog_tag.id, blog_tag.name
-		FROM blog_tag
-		INNER JOIN blog_post_tag ON blog_post_tag.tag_id = blog_tag.id
-		WHERE blog_post_tag.post_id = @post_id;
+	historySql := `
+		INSERT INTO blog_post_slug (slug, post_id)
+		VALUES (@slug, @post_id)
+		ON CONFLICT (slug) DO UPDATE SET post_id = EXCLUDED.post_id, created_at = CURRENT_TIMESTAMP;
 	`
-	tagRows, err := s.Conn.Query(config.CTX, tagSql, pgx.NamedArgs{"post_id": value.Id})
+	_, err := tx.Exec(ctx, historySql, pgx.NamedArgs{"slug": oldSlug, "post_id": postId})
+	return err
+}
+
+// PublishDue publishes the scheduled posts whose publish_at is not after
+// now and returns their ids. Only one instance publishes at a time, the
+// others return nothing until the next run.
+func (s *BlogPostService) PublishDue(ctx context.Context, now time.Time) ([]string, error) {
+	value := []string{}
+	tx, err := s.Conn.Begin(ctx)
 	if err != nil {
-		return value, err
+		return value, databaseError(err)
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
-	for tagRows.Next() {
-		tagItem := models.BlogTag{}
-		if err := tagRows.Scan(&tagItem.Id, &tagItem.Name); err != nil {
-			return value, err
-		}
-		value.Tags = append(value.Tags, tagItem)
+	// Skip this run when another instance holds the lock
+	locked := false
+	err = tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock(@lock_id);", pgx.NamedArgs{"lock_id": publishLockId}).Scan(&locked)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	if !locked {
+		return value, nil
+	}
+
+	// Publish due posts
+	sql := `
+		UPDATE blog_post SET is_draft = FALSE
+		WHERE is_draft AND publish_at <= @now
+		RETURNING id;
+	`
+	rows, err := tx.Query(ctx, sql, pgx.NamedArgs{"now": now})
+	if err != nil {
+		return value, databaseError(err)
+	}
+	value, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return value, databaseError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return value, databaseError(err)
 	}
 
-	// If success return nil
 	return value, nil
 }
 
-func (s *BlogPostService) Remove(id string) (string, error) {
+// ReindexSearch indexes again the posts indexed with another language than
+// the configured one, after the language changed, and returns their count
+func (s *BlogPostService) ReindexSearch(ctx context.Context) (int64, error) {
+	sql := `
+		UPDATE blog_post SET
+			search_vector=` + searchVector("title", "content") + `,
+			search_language=@language
+		WHERE search_language <> @language;
+	`
+	tag, err := s.Conn.Exec(ctx, sql, pgx.NamedArgs{"language": s.Conn.SearchLanguage})
+	if err != nil {
+		return 0, databaseError(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (s *BlogPostService) Remove(ctx context.Context, id string) (string, error) {
 	// Execute SQL
 	sql := "DELETE FROM blog_post WHERE id=@id RETURNING id;"
 	args := pgx.NamedArgs{
 		"id": id,
 	}
 	value := ""
-	err := s.Conn.QueryRow(config.CTX, sql, args).Scan(&value)
+	err := s.Conn.QueryRow(ctx, sql, args).Scan(&value)
 	if err != nil {
-		return value, err
+		return value, databaseError(err)
 	}
 
 	// If success return nil
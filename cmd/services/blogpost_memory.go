@@ -0,0 +1,472 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type MemoryBlogPostService struct {
+	Store *MemoryStore
+}
+
+func NewMemoryBlogPostService(store *MemoryStore) *MemoryBlogPostService {
+	return &MemoryBlogPostService{Store: store}
+}
+
+// hasStatus behaves like statusCondition
+func hasStatus(post models.BlogPostContentWithTags, status string) bool {
+	switch status {
+	case models.STATUS_ALL:
+		return true
+	case models.STATUS_DRAFT:
+		return post.IsDraft && post.PublishAt == nil
+	case models.STATUS_SCHEDULED:
+		return post.IsDraft && post.PublishAt != nil
+	default:
+		return !post.IsDraft
+	}
+}
+
+// hasTags behaves like the tag conditions of postFilter
+func hasTags(postTags []models.BlogTag, filter models.BlogPostFilter) bool {
+	has := func(name string) bool {
+		return slices.ContainsFunc(postTags, func(tag models.BlogTag) bool { return tag.Name == name })
+	}
+
+	// Every tag is needed unless any of them is enough
+	if names := tagNames(filter.Tags); len(names) > 0 {
+		matched := 0
+		for _, name := range names {
+			if has(name) {
+				matched++
+			}
+		}
+		if matched == 0 || (filter.TagMode != models.TAG_MODE_ANY && matched < len(names)) {
+			return false
+		}
+	}
+
+	return !slices.ContainsFunc(tagNames(filter.ExcludeTags), has)
+}
+
+// inRange behaves like the range conditions of postFilter
+func inRange(post models.BlogPostContentWithTags, filter models.BlogPostFilter) bool {
+	if filter.From != nil && post.CreatedAt.Before(*filter.From) {
+		return false
+	}
+	return filter.To == nil || post.CreatedAt.Before(*filter.To)
+}
+
+// filter returns the posts matching search, status, tags and range in the
+// order of the sort
+func (s *MemoryBlogPostService) filter(filter models.BlogPostFilter) []models.BlogPostContentWithTags {
+	value := []models.BlogPostContentWithTags{}
+	query := parseMemoryQuery(filter.Search)
+	ranks := map[string]float64{}
+	for _, post := range s.Store.posts {
+		post.Tags = s.Store.postTagsOf(post.Id)
+		rank, ok := query.rank(post.Title, post.Content)
+		if !ok || !hasStatus(post, filter.Status) || !hasTags(post.Tags, filter) || !inRange(post, filter) {
+			continue
+		}
+
+		if filter.Search != "" {
+			post.Snippet = query.snippet(post.Content)
+		}
+		ranks[post.Id] = rank
+		value = append(value, post)
+	}
+
+	sortPosts(value, postSort(filter.Sort, filter.Search), ranks)
+	return value
+}
+
+func (s *MemoryBlogPostService) Count(ctx context.Context, filter models.BlogPostFilter) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	return len(s.filter(filter)), nil
+}
+
+func (s *MemoryBlogPostService) GetWithSlug(ctx context.Context, slug string, status string) (models.BlogPostContentWithTags, error) {
+	if err := ctx.Err(); err != nil {
+		return models.BlogPostContentWithTags{}, err
+	}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	i := s.Store.findPostWithSlug(slug)
+	if i < 0 {
+		return models.BlogPostContentWithTags{}, s.Store.movedError(slug, status)
+	}
+	if !hasStatus(s.Store.posts[i], status) {
+		return models.BlogPostContentWithTags{}, ErrNotFound
+	}
+
+	value := s.Store.posts[i]
+	value.Tags = s.Store.postTagsOf(value.Id)
+	value.Series = s.Store.postSeries(value.Id, status)
+	return value, nil
+}
+
+func (s *MemoryBlogPostService) GetWithId(ctx context.Context, id string) (models.BlogPostContentWithTags, error) {
+	if err := ctx.Err(); err != nil {
+		return models.BlogPostContentWithTags{}, err
+	}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	i := s.Store.findPost(id)
+	if i < 0 {
+		return models.BlogPostContentWithTags{}, ErrNotFound
+	}
+
+	value := s.Store.posts[i]
+	value.Tags = s.Store.postTagsOf(value.Id)
+	return value, nil
+}
+
+func (s *MemoryBlogPostService) GetAll(ctx context.Context, filter models.BlogPostFilter, limit int, page int) ([]models.BlogPostWithTags, models.PageMeta, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, models.PageMeta{}, err
+	}
+
+	posts, meta, err := s.GetAllWithContent(ctx, filter, limit, page)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	value := []models.BlogPostWithTags{}
+	for _, post := range posts {
+		value = append(value, models.BlogPostWithTags{
+			Id:                 post.Id,
+			Title:              post.Title,
+			Slug:               post.Slug,
+			Excerpt:            post.Excerpt,
+			WordCount:          post.WordCount,
+			ReadingTimeMinutes: post.ReadingTimeMinutes,
+			CreatedAt:          post.CreatedAt,
+			UpdatedAt:          post.UpdatedAt,
+			IsDraft:            post.IsDraft,
+			PublishAt:          post.PublishAt,
+			Tags:               post.Tags,
+			Snippet:            post.Snippet,
+		})
+	}
+	return value, meta, nil
+}
+
+func (s *MemoryBlogPostService) GetAllWithCursor(ctx context.Context, filter models.BlogPostFilter, cursor *models.Cursor, limit int) ([]models.BlogPostWithTags, models.Cursors, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, models.Cursors{}, err
+	}
+
+	limit = postLimit(limit)
+	if cursor != nil {
+		filter.Sort = cursor.Sort
+	}
+	sort := postSort(filter.Sort, filter.Search)
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	posts := s.filter(filter)
+	query := parseMemoryQuery(filter.Search)
+	rank := func(post models.BlogPostContentWithTags) float64 {
+		value, _ := query.rank(post.Title, post.Content)
+		return value
+	}
+
+	// Keep the posts after the cursor
+	if cursor != nil {
+		probe, probeRank, err := postFromKeys(cursor.Keys, sort)
+		if err != nil {
+			return nil, models.Cursors{}, err
+		}
+		posts = afterCursor(posts, cursor, limit, func(post models.BlogPostContentWithTags) int {
+			return comparePosts(post, rank(post), probe, probeRank, sort)
+		})
+	} else {
+		posts = afterCursor(posts, nil, limit, nil)
+	}
+
+	value := []models.BlogPostWithTags{}
+	keys := [][]string{}
+	for _, post := range posts {
+		value = append(value, models.BlogPostWithTags{
+			Id:                 post.Id,
+			Title:              post.Title,
+			Slug:               post.Slug,
+			Excerpt:            post.Excerpt,
+			WordCount:          post.WordCount,
+			ReadingTimeMinutes: post.ReadingTimeMinutes,
+			CreatedAt:          post.CreatedAt,
+			UpdatedAt:          post.UpdatedAt,
+			IsDraft:            post.IsDraft,
+			PublishAt:          post.PublishAt,
+			Tags:               post.Tags,
+			Snippet:            post.Snippet,
+		})
+		keys = append(keys, postKeys(post, rank(post), sort))
+	}
+	value, cursors := cursorPage(value, keys, sort, cursor, limit)
+	return value, cursors, nil
+}
+
+func (s *MemoryBlogPostService) GetAllWithContent(ctx context.Context, filter models.BlogPostFilter, limit int, page int) ([]models.BlogPostContentWithTags, models.PageMeta, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, models.PageMeta{}, err
+	}
+
+	// Set default range for limit and page
+	limit, page = postLimit(limit), pageNumber(page)
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	posts := s.filter(filter)
+	start, end := paginate(len(posts), limit, (page-1)*limit)
+	return posts[start:end], newPageMeta(len(posts), limit, page), nil
+}
+
+func (s *MemoryBlogPostService) GetArchive(ctx context.Context, filter models.BlogPostFilter, withPosts bool) ([]models.BlogPostArchiveYear, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Every post is added newest first
+	filter.Sort = models.Sort{Field: models.SORT_CREATED_AT, Order: models.ORDER_DESC}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	value := []models.BlogPostArchiveYear{}
+	for _, post := range s.filter(filter) {
+		createdAt := post.CreatedAt.UTC()
+		var archivePost *models.BlogPostArchivePost
+		if withPosts {
+			archivePost = &models.BlogPostArchivePost{Id: post.Id, Title: post.Title, Slug: post.Slug, CreatedAt: post.CreatedAt}
+		}
+		value = addToArchive(value, createdAt.Year(), createdAt.Month(), 1, archivePost)
+	}
+	return value, nil
+}
+
+// GetRelated behaves like the Postgres GetRelated
+func (s *MemoryBlogPostService) GetRelated(ctx context.Context, slug string, status string, limit int) ([]models.BlogPostWithTags, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	i := s.Store.findPostWithSlug(slug)
+	if i < 0 {
+		return nil, s.Store.movedError(slug, status)
+	}
+	if !hasStatus(s.Store.posts[i], status) {
+		return nil, ErrNotFound
+	}
+	source := s.Store.posts[i]
+
+	// Count the published posts having each tag
+	usage := map[string]int{}
+	sourceTags := map[string]bool{}
+	for _, link := range s.Store.postTags {
+		if j := s.Store.findPost(link.postId); j >= 0 && hasStatus(s.Store.posts[j], models.STATUS_PUBLISHED) {
+			usage[link.tagId]++
+		}
+		if link.postId == source.Id {
+			sourceTags[link.tagId] = true
+		}
+	}
+
+	// Score the other published posts on the tags they share with the post
+	scores := map[string]float64{}
+	for _, link := range s.Store.postTags {
+		if link.postId != source.Id && sourceTags[link.tagId] {
+			scores[link.postId] += relatedWeight(usage[link.tagId])
+		}
+	}
+	posts := []models.BlogPostContentWithTags{}
+	for _, post := range s.Store.posts {
+		if _, ok := scores[post.Id]; ok && hasStatus(post, models.STATUS_PUBLISHED) {
+			scores[post.Id] = relatedScore(scores[post.Id])
+			posts = append(posts, post)
+		}
+	}
+	slices.SortFunc(posts, func(a, b models.BlogPostContentWithTags) int {
+		return cmp.Or(
+			cmp.Compare(scores[b.Id], scores[a.Id]),
+			b.CreatedAt.Compare(a.CreatedAt),
+			strings.Compare(b.Id, a.Id),
+		)
+	})
+	posts = posts[:min(len(posts), relatedLimit(limit))]
+
+	value := []models.BlogPostWithTags{}
+	for _, post := range posts {
+		value = append(value, models.BlogPostWithTags{
+			Id:                 post.Id,
+			Title:              post.Title,
+			Slug:               post.Slug,
+			Excerpt:            post.Excerpt,
+			WordCount:          post.WordCount,
+			ReadingTimeMinutes: post.ReadingTimeMinutes,
+			CreatedAt:          post.CreatedAt,
+			UpdatedAt:          post.UpdatedAt,
+			IsDraft:            post.IsDraft,
+			PublishAt:          post.PublishAt,
+			Tags:               s.Store.postTagsOf(post.Id),
+		})
+	}
+	return value, nil
+}
+
+func (s *MemoryBlogPostService) Create(ctx context.Context, input *models.BlogPostCreated) (models.BlogPostContentWithTags, error) {
+	if err := ctx.Err(); err != nil {
+		return models.BlogPostContentWithTags{}, err
+	}
+
+	s.Store.mu.Lock()
+	defer s.Store.mu.Unlock()
+
+	// Get slug string
+	slugString := s.Store.chooseSlug(input.Title, input.Slug, "")
+	if s.Store.findPostWithSlug(slugString) >= 0 {
+		return models.BlogPostContentWithTags{}, fmt.Errorf("%w: post slug %q already exists", ErrConflict, slugString)
+	}
+
+	// Create post with the stats of its content
+	stats := postStats(input.Summary, input.Content)
+	value := models.BlogPostContentWithTags{
+		Id:                 newMemoryId(),
+		Title:              input.Title,
+		Slug:               slugString,
+		Summary:            input.Summary,
+		Excerpt:            stats.Excerpt,
+		WordCount:          stats.WordCount,
+		ReadingTimeMinutes: stats.ReadingTimeMinutes,
+		Content:            input.Content,
+		CreatedAt:          input.CreatedAt,
+		UpdatedAt:          input.UpdatedAt,
+		IsDraft:            input.IsDraft,
+		PublishAt:          input.PublishAt,
+	}
+	if err := s.Store.setPostTags(value.Id, input.Tags); err != nil {
+		return models.BlogPostContentWithTags{}, err
+	}
+	s.Store.setSlugHistory(value.Id, "", value.Slug)
+	s.Store.posts = append(s.Store.posts, value)
+
+	value.Tags = s.Store.postTagsOf(value.Id)
+	return value, nil
+}
+
+func (s *MemoryBlogPostService) Update(ctx context.Context, input *models.BlogPostUpdated) (models.BlogPostContentWithTags, error) {
+	if err := ctx.Err(); err != nil {
+		return models.BlogPostContentWithTags{}, err
+	}
+
+	s.Store.mu.Lock()
+	defer s.Store.mu.Unlock()
+
+	i := s.Store.findPost(input.Id)
+	if i < 0 {
+		return models.BlogPostContentWithTags{}, ErrNotFound
+	}
+
+	// Get slug string, keeping the current one when it is custom or still
+	// fits the title
+	previous := s.Store.posts[i]
+	slugString := s.Store.chooseSlug(input.Title, updateSlug(input.Slug, previous.Slug, previous.Title), previous.Slug)
+	if j := s.Store.findPostWithSlug(slugString); j >= 0 && j != i {
+		return models.BlogPostContentWithTags{}, fmt.Errorf("%w: post slug %q already exists", ErrConflict, slugString)
+	}
+
+	// Update post with the stats of its content
+	stats := postStats(input.Summary, input.Content)
+	value := models.BlogPostContentWithTags{
+		Id:                 input.Id,
+		Title:              input.Title,
+		Slug:               slugString,
+		Summary:            input.Summary,
+		Excerpt:            stats.Excerpt,
+		WordCount:          stats.WordCount,
+		ReadingTimeMinutes: stats.ReadingTimeMinutes,
+		Content:            input.Content,
+		CreatedAt:          input.CreatedAt,
+		UpdatedAt:          input.UpdatedAt,
+		IsDraft:            input.IsDraft,
+		PublishAt:          input.PublishAt,
+	}
+	if err := s.Store.setPostTags(value.Id, input.Tags); err != nil {
+		return models.BlogPostContentWithTags{}, err
+	}
+	s.Store.createRevision(i)
+	s.Store.setSlugHistory(value.Id, s.Store.posts[i].Slug, value.Slug)
+	s.Store.posts[i] = value
+
+	value.Tags = s.Store.postTagsOf(value.Id)
+	return value, nil
+}
+
+func (s *MemoryBlogPostService) PublishDue(ctx context.Context, now time.Time) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.Store.mu.Lock()
+	defer s.Store.mu.Unlock()
+
+	value := []string{}
+	for i, post := range s.Store.posts {
+		if post.IsDraft && post.PublishAt != nil && !post.PublishAt.After(now) {
+			s.Store.posts[i].IsDraft = false
+			value = append(value, post.Id)
+		}
+	}
+	return value, nil
+}
+
+func (s *MemoryBlogPostService) Remove(ctx context.Context, id string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	s.Store.mu.Lock()
+	defer s.Store.mu.Unlock()
+
+	i := s.Store.findPost(id)
+	if i < 0 {
+		return "", ErrNotFound
+	}
+
+	// Remove post, its tag links, revisions, old slugs and series parts like
+	// ON DELETE CASCADE
+	s.Store.posts = append(s.Store.posts[:i], s.Store.posts[i+1:]...)
+	s.Store.removePostTags(func(link memoryPostTag) bool { return link.postId == id })
+	kept := s.Store.revisions[:0]
+	for _, revision := range s.Store.revisions {
+		if revision.PostId != id {
+			kept = append(kept, revision)
+		}
+	}
+	s.Store.revisions = kept
+	s.Store.removeSlugs(func(old memorySlug) bool { return old.postId == id })
+	s.Store.removeSeriesParts(func(part memorySeriesPart) bool { return part.postId == id })
+
+	return id, nil
+}
@@ -0,0 +1,680 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosimple/slug"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryBlogPostService(t *testing.T) {
+	ctx := context.Background()
+	id := ""
+	store := NewMemoryStore()
+	tagService := MemoryBlogTagService{Store: store}
+	postService := MemoryBlogPostService{Store: store}
+	tagValue1, err := tagService.Create(ctx, &models.BlogTag{Name: "website"})
+	assert.NoError(t, err)
+	tagValue2, err := tagService.Create(ctx, &models.BlogTag{Name: "technology"})
+	assert.NoError(t, err)
+	tagValue3, err := tagService.Create(ctx, &models.BlogTag{Name: "life"})
+	assert.NoError(t, err)
+
+	t.Run("Create success", func(t *testing.T) {
+		// Declare input
+		input := models.BlogPostCreated{
+			Title:     "new post",
+			Content:   "## Hello new post!",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			IsDraft:   true,
+			Tags:      []models.BlogTag{tagValue1, tagValue2},
+		}
+
+		// Create post
+		value, err := postService.Create(ctx, &input)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, value.Id)
+		assert.Equal(t, input.Title, value.Title)
+		assert.Equal(t, slug.Make(input.Title), value.Slug)
+		assert.Equal(t, input.Content, value.Content)
+		assert.Equal(t, input.IsDraft, value.IsDraft)
+		assert.Equal(t, []models.BlogTag{tagValue1, tagValue2}, value.Tags)
+
+		// Assign value to id
+		id = value.Id
+	})
+
+	t.Run("Create success with colliding title", func(t *testing.T) {
+		// Generated slugs are numbered
+		value, err := postService.Create(ctx, &models.BlogPostCreated{Title: "New post"})
+		assert.NoError(t, err)
+		assert.Equal(t, "new-post-2", value.Slug)
+
+		// Saving again keeps the number
+		value, err = postService.Update(ctx, &models.BlogPostUpdated{Id: value.Id, Title: "New post"})
+		assert.NoError(t, err)
+		assert.Equal(t, "new-post-2", value.Slug)
+
+		_, err = postService.Remove(ctx, value.Id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Create failed with duplicate custom slug", func(t *testing.T) {
+		input := models.BlogPostCreated{Title: "another post", Slug: "new-post"}
+
+		_, err := postService.Create(ctx, &input)
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+
+	t.Run("Create success with custom slug", func(t *testing.T) {
+		value, err := postService.Create(ctx, &models.BlogPostCreated{Title: "another post", Slug: "my-own-slug"})
+		assert.NoError(t, err)
+		assert.Equal(t, "my-own-slug", value.Slug)
+
+		// Reserved words are numbered when made from a title
+		reserved, err := postService.Create(ctx, &models.BlogPostCreated{Title: "Archive"})
+		assert.NoError(t, err)
+		assert.Equal(t, "archive-2", reserved.Slug)
+
+		_, err = postService.Remove(ctx, value.Id)
+		assert.NoError(t, err)
+		_, err = postService.Remove(ctx, reserved.Id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Update success keeps custom slug", func(t *testing.T) {
+		value, err := postService.Create(ctx, &models.BlogPostCreated{Title: "kept title", Slug: "kept-custom-slug"})
+		assert.NoError(t, err)
+
+		// Updates without a slug don't replace a custom one with the title
+		value, err = postService.Update(ctx, &models.BlogPostUpdated{Id: value.Id, Title: "kept title changed"})
+		assert.NoError(t, err)
+		assert.Equal(t, "kept-custom-slug", value.Slug)
+
+		_, err = postService.Remove(ctx, value.Id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Create success with stats", func(t *testing.T) {
+		input := models.BlogPostCreated{
+			Title:   "stats post",
+			Content: "# Stats\n\nFirst *paragraph* here.\n\n" + strings.Repeat("word ", 300),
+		}
+		value, err := postService.Create(ctx, &input)
+		assert.NoError(t, err)
+		assert.Equal(t, 304, value.WordCount)
+		assert.Equal(t, 2, value.ReadingTimeMinutes)
+		assert.True(t, strings.HasPrefix(value.Excerpt, "First paragraph here. word word"))
+
+		// The summary replaces the excerpt, list items keep the stats
+		updated, err := postService.Update(ctx, &models.BlogPostUpdated{Id: value.Id, Title: input.Title, Summary: "Short summary", Content: input.Content})
+		assert.NoError(t, err)
+		assert.Equal(t, "Short summary", updated.Excerpt)
+		list, _, err := postService.GetAll(ctx, models.BlogPostFilter{Search: "stats post", Status: models.STATUS_ALL}, 10, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(list))
+		assert.Equal(t, "Short summary", list[0].Excerpt)
+		assert.Equal(t, 304, list[0].WordCount)
+		assert.Equal(t, 2, list[0].ReadingTimeMinutes)
+
+		_, err = postService.Remove(ctx, value.Id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Create failed with unknown tag", func(t *testing.T) {
+		input := models.BlogPostCreated{
+			Title: "unknown tag post",
+			Tags:  []models.BlogTag{{Id: newMemoryId()}},
+		}
+
+		_, err := postService.Create(ctx, &input)
+		invalidTagErr := &InvalidTagError{}
+		assert.ErrorAs(t, err, &invalidTagErr)
+		assert.Equal(t, input.Tags[0].Id, invalidTagErr.TagId)
+
+		count, err := postService.Count(ctx, models.BlogPostFilter{Search: "unknown tag", Tags: []models.BlogTag{}, Status: models.STATUS_ALL})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Update success", func(t *testing.T) {
+		// Declare input
+		input := models.BlogPostUpdated{
+			Id:        id,
+			Title:     "My test post",
+			Content:   "## Hello my test post!",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			IsDraft:   true,
+			Tags:      []models.BlogTag{tagValue1, tagValue3},
+		}
+
+		// Update post
+		value, err := postService.Update(ctx, &input)
+		assert.NoError(t, err)
+		assert.Equal(t, input.Title, value.Title)
+		assert.Equal(t, slug.Make(input.Title), value.Slug)
+		assert.Equal(t, input.Content, value.Content)
+		assert.Equal(t, []models.BlogTag{tagValue1, tagValue3}, value.Tags)
+	})
+
+	t.Run("Get with slug success", func(t *testing.T) {
+		data, err := postService.GetWithSlug(ctx, "my-test-post", models.STATUS_ALL)
+		assert.NoError(t, err)
+		assert.Equal(t, id, data.Id)
+		assert.Equal(t, []models.BlogTag{tagValue1, tagValue3}, data.Tags)
+
+		// The slug before the update points at the current one
+		_, err = postService.GetWithSlug(ctx, "new-post", models.STATUS_ALL)
+		moved := &MovedError{}
+		assert.ErrorAs(t, err, &moved)
+		assert.Equal(t, "my-test-post", moved.Slug)
+
+		// Unless the post itself is hidden
+		_, err = postService.GetWithSlug(ctx, "new-post", models.STATUS_PUBLISHED)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Get with id success", func(t *testing.T) {
+		// Drafts are found by id whatever the status
+		data, err := postService.GetWithId(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, "my-test-post", data.Slug)
+		assert.True(t, data.IsDraft)
+		assert.Equal(t, []models.BlogTag{tagValue1, tagValue3}, data.Tags)
+
+		_, err = postService.GetWithId(ctx, newMemoryId())
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Remove success", func(t *testing.T) {
+		value, err := postService.Remove(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, id, value)
+
+		_, err = postService.GetWithSlug(ctx, "my-test-post", models.STATUS_ALL)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("GetAll and Count success", func(t *testing.T) {
+		// Create data
+		inputPost1 := models.BlogPostCreated{
+			Title:     "new post",
+			Content:   "## Hello new post!",
+			CreatedAt: time.Now().Add(-time.Hour),
+			IsDraft:   true,
+			Tags:      []models.BlogTag{tagValue1, tagValue2},
+		}
+		inputPost2 := models.BlogPostCreated{
+			Title:     "My test post",
+			Content:   "## Hello my test post!",
+			CreatedAt: time.Now(),
+			IsDraft:   true,
+			Tags:      []models.BlogTag{tagValue2, tagValue3},
+		}
+		valuePost1, err := postService.Create(ctx, &inputPost1)
+		assert.NoError(t, err)
+		valuePost2, err := postService.Create(ctx, &inputPost2)
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, valuePost1.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, valuePost2.Id)
+			assert.NoError(t, err)
+		}()
+
+		tests := []struct {
+			name    string
+			search  string
+			tags    []models.BlogTag
+			tagMode string
+			exclude []models.BlogTag
+			ids     []string
+		}{
+			{"default", "", []models.BlogTag{}, "", nil, []string{valuePost2.Id, valuePost1.Id}},
+			{"with search", "TEST", []models.BlogTag{}, "", nil, []string{valuePost2.Id}},
+			{"with tags", "", []models.BlogTag{tagValue1, tagValue2}, "", nil, []string{valuePost1.Id}},
+			{"with shared tag", "", []models.BlogTag{tagValue2}, "", nil, []string{valuePost2.Id, valuePost1.Id}},
+			{"with search and tags", "new", []models.BlogTag{tagValue3}, "", nil, []string{}},
+			{"with all tags", "", []models.BlogTag{tagValue1, tagValue3}, models.TAG_MODE_ALL, nil, []string{}},
+			{"with any tag", "", []models.BlogTag{tagValue1, tagValue3}, models.TAG_MODE_ANY, nil, []string{valuePost2.Id, valuePost1.Id}},
+			{"with repeated tag", "", []models.BlogTag{tagValue2, tagValue2}, "", nil, []string{valuePost2.Id, valuePost1.Id}},
+			{"with excluded tag", "", []models.BlogTag{}, "", []models.BlogTag{tagValue1}, []string{valuePost2.Id}},
+			{"with any and excluded tag", "", []models.BlogTag{tagValue1, tagValue3}, models.TAG_MODE_ANY, []models.BlogTag{tagValue3}, []string{valuePost1.Id}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				filter := models.BlogPostFilter{Search: test.search, Tags: test.tags, TagMode: test.tagMode, ExcludeTags: test.exclude, Status: models.STATUS_ALL}
+				data, _, err := postService.GetAll(ctx, filter, 10, 1)
+				assert.NoError(t, err)
+				ids := []string{}
+				for _, post := range data {
+					ids = append(ids, post.Id)
+				}
+				assert.Equal(t, test.ids, ids)
+
+				dataWithContent, _, err := postService.GetAllWithContent(ctx, filter, 10, 1)
+				assert.NoError(t, err)
+				assert.Equal(t, len(test.ids), len(dataWithContent))
+				for _, post := range dataWithContent {
+					assert.NotEmpty(t, post.Content)
+				}
+
+				count, err := postService.Count(ctx, filter)
+				assert.NoError(t, err)
+				assert.Equal(t, len(test.ids), count)
+			})
+		}
+	})
+
+	t.Run("GetAll success with full text search", func(t *testing.T) {
+		// Create data
+		inputPost1 := models.BlogPostCreated{
+			Title:     "Channels in Go",
+			Content:   "Use select with channels. Channels are typed.",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		inputPost2 := models.BlogPostCreated{
+			Title:     "Mutex basics",
+			Content:   "A mutex guards shared state, channels are another way.",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		valuePost1, err := postService.Create(ctx, &inputPost1)
+		assert.NoError(t, err)
+		valuePost2, err := postService.Create(ctx, &inputPost2)
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, valuePost1.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, valuePost2.Id)
+			assert.NoError(t, err)
+		}()
+
+		tests := []struct {
+			name   string
+			search string
+			ids    []string
+		}{
+			{"ranked by matches", "channels", []string{valuePost1.Id, valuePost2.Id}},
+			{"with excluded word", "channels -mutex", []string{valuePost1.Id}},
+			{"with phrase", `"shared state"`, []string{valuePost2.Id}},
+			{"with or", "typed or guards shared", []string{valuePost2.Id, valuePost1.Id}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				data, _, err := postService.GetAll(ctx, models.BlogPostFilter{Search: test.search, Tags: []models.BlogTag{}, Status: models.STATUS_ALL}, 10, 1)
+				assert.NoError(t, err)
+				ids := []string{}
+				for _, post := range data {
+					ids = append(ids, post.Id)
+					assert.Contains(t, post.Snippet, "<mark>")
+				}
+				assert.Equal(t, test.ids, ids)
+
+				count, err := postService.Count(ctx, models.BlogPostFilter{Search: test.search, Tags: []models.BlogTag{}, Status: models.STATUS_ALL})
+				assert.NoError(t, err)
+				assert.Equal(t, len(test.ids), count)
+			})
+		}
+
+		// Snippets are only made when searching
+		data, _, err := postService.GetAllWithContent(ctx, models.BlogPostFilter{Tags: []models.BlogTag{}, Status: models.STATUS_ALL}, 10, 1)
+		assert.NoError(t, err)
+		for _, post := range data {
+			assert.Empty(t, post.Snippet)
+		}
+	})
+
+	t.Run("GetAll success with sort", func(t *testing.T) {
+		// Create data
+		now := time.Now()
+		first, err := postService.Create(ctx, &models.BlogPostCreated{Title: "sorted b", CreatedAt: now.Add(-time.Hour), UpdatedAt: now})
+		assert.NoError(t, err)
+		second, err := postService.Create(ctx, &models.BlogPostCreated{Title: "sorted a", CreatedAt: now, UpdatedAt: now.Add(-time.Hour)})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, first.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, second.Id)
+			assert.NoError(t, err)
+		}()
+
+		tests := []struct {
+			name string
+			sort models.Sort
+			ids  []string
+		}{
+			{"most relevant then newest", models.Sort{}, []string{second.Id, first.Id}},
+			{"oldest first", models.Sort{Field: models.SORT_CREATED_AT, Order: models.ORDER_ASC}, []string{first.Id, second.Id}},
+			{"updated", models.Sort{Field: models.SORT_UPDATED_AT}, []string{first.Id, second.Id}},
+			{"title", models.Sort{Field: models.SORT_TITLE}, []string{second.Id, first.Id}},
+			{"title descending", models.Sort{Field: models.SORT_TITLE, Order: models.ORDER_DESC}, []string{first.Id, second.Id}},
+		}
+		for _, test := range tests {
+			filter := models.BlogPostFilter{Search: "sorted", Status: models.STATUS_ALL, Sort: test.sort}
+			data, _, err := postService.GetAll(ctx, filter, 10, 1)
+			assert.NoError(t, err)
+			ids := []string{}
+			for _, post := range data {
+				ids = append(ids, post.Id)
+			}
+			assert.Equal(t, test.ids, ids, test.name)
+		}
+	})
+
+	t.Run("GetAll and Count success with status", func(t *testing.T) {
+		now := time.Now()
+		draft, err := postService.Create(ctx, &models.BlogPostCreated{Title: "status draft", CreatedAt: now.Add(-2 * time.Hour), IsDraft: true})
+		assert.NoError(t, err)
+		published, err := postService.Create(ctx, &models.BlogPostCreated{Title: "status published", CreatedAt: now.Add(-time.Hour)})
+		assert.NoError(t, err)
+		publishAt := now.Add(time.Hour)
+		scheduled, err := postService.Create(ctx, &models.BlogPostCreated{Title: "status scheduled", CreatedAt: now, IsDraft: true, PublishAt: &publishAt})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, draft.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, published.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, scheduled.Id)
+			assert.NoError(t, err)
+		}()
+
+		tests := []struct {
+			status string
+			ids    []string
+		}{
+			{"", []string{published.Id}},
+			{models.STATUS_PUBLISHED, []string{published.Id}},
+			{models.STATUS_DRAFT, []string{draft.Id}},
+			{models.STATUS_SCHEDULED, []string{scheduled.Id}},
+			{models.STATUS_ALL, []string{scheduled.Id, published.Id, draft.Id}},
+		}
+		for _, test := range tests {
+			filter := models.BlogPostFilter{Search: "status", Status: test.status}
+			data, _, err := postService.GetAll(ctx, filter, 10, 1)
+			assert.NoError(t, err)
+			ids := []string{}
+			for _, post := range data {
+				ids = append(ids, post.Id)
+			}
+			assert.Equal(t, test.ids, ids, test.status)
+
+			count, err := postService.Count(ctx, filter)
+			assert.NoError(t, err)
+			assert.Equal(t, len(test.ids), count, test.status)
+		}
+
+		// Drafts are not found when only published posts are asked for
+		_, err = postService.GetWithSlug(ctx, draft.Slug, models.STATUS_PUBLISHED)
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = postService.GetWithSlug(ctx, draft.Slug, models.STATUS_ALL)
+		assert.NoError(t, err)
+	})
+
+	t.Run("GetAll success with pagination", func(t *testing.T) {
+		// Create more posts than one page holds
+		now := time.Now()
+		for i := range 12 {
+			_, err := postService.Create(ctx, &models.BlogPostCreated{Title: "paged post " + string(rune('a'+i)), CreatedAt: now.Add(time.Duration(i) * time.Minute)})
+			assert.NoError(t, err)
+		}
+
+		// Limit is clamped to at least 10
+		data, meta, err := postService.GetAll(ctx, models.BlogPostFilter{Search: "paged", Tags: []models.BlogTag{}, Status: models.STATUS_ALL}, 1, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, len(data))
+		assert.Equal(t, models.PageMeta{Total: 12, Page: 1, Limit: 10, TotalPages: 2, HasNext: true}, meta)
+
+		data, meta, err = postService.GetAll(ctx, models.BlogPostFilter{Search: "paged", Tags: []models.BlogTag{}, Status: models.STATUS_ALL}, 10, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(data))
+		assert.Equal(t, "paged post b", data[0].Title)
+		assert.Equal(t, models.PageMeta{Total: 12, Page: 2, Limit: 10, TotalPages: 2, HasNext: false}, meta)
+	})
+
+	t.Run("GetAllWithCursor success", func(t *testing.T) {
+		// Create more posts than one page holds, two of them at the same time
+		now := time.Now()
+		for i := range 12 {
+			_, err := postService.Create(ctx, &models.BlogPostCreated{Title: "cursor post " + string(rune('a'+i)), CreatedAt: now.Add(time.Duration(min(i, 10)) * time.Minute)})
+			assert.NoError(t, err)
+		}
+		filter := models.BlogPostFilter{Search: "cursor", Status: models.STATUS_ALL, Sort: models.Sort{Field: models.SORT_CREATED_AT}}
+		all, _, err := postService.GetAll(ctx, filter, 50, 1)
+		assert.NoError(t, err)
+
+		// The first page has no previous page
+		first, cursors, err := postService.GetAllWithCursor(ctx, filter, nil, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, all[:10], first)
+		assert.NotEmpty(t, cursors.Next)
+		assert.Empty(t, cursors.Prev)
+
+		// The last page has no next page
+		next, err := models.DecodeCursor(cursors.Next)
+		assert.NoError(t, err)
+		second, cursors, err := postService.GetAllWithCursor(ctx, filter, &next, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, all[10:], second)
+		assert.Empty(t, cursors.Next)
+		assert.NotEmpty(t, cursors.Prev)
+
+		// Going back returns the first page again
+		prev, err := models.DecodeCursor(cursors.Prev)
+		assert.NoError(t, err)
+		data, cursors, err := postService.GetAllWithCursor(ctx, filter, &prev, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, first, data)
+		assert.NotEmpty(t, cursors.Next)
+		assert.Empty(t, cursors.Prev)
+
+		// The sort of the cursor wins over the one of the filter
+		filter.Sort = models.Sort{Field: models.SORT_TITLE}
+		data, _, err = postService.GetAllWithCursor(ctx, filter, &next, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, second, data)
+
+		// Cursors that don't fit the sort are refused
+		_, _, err = postService.GetAllWithCursor(ctx, filter, &models.Cursor{Sort: next.Sort, Keys: []string{"yesterday", next.Keys[1]}}, 10)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		_, _, err = postService.GetAllWithCursor(ctx, filter, &models.Cursor{Sort: next.Sort}, 10)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("GetAll and Count success with date range", func(t *testing.T) {
+		march, err := postService.Create(ctx, &models.BlogPostCreated{Title: "ranged march", CreatedAt: time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)})
+		assert.NoError(t, err)
+		april, err := postService.Create(ctx, &models.BlogPostCreated{Title: "ranged april", CreatedAt: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, march.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, april.Id)
+			assert.NoError(t, err)
+		}()
+
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+		tests := []struct {
+			name string
+			from *time.Time
+			to   *time.Time
+			ids  []string
+		}{
+			{"no range", nil, nil, []string{april.Id, march.Id}},
+			{"from is included", &end, nil, []string{april.Id}},
+			{"to is excluded", &start, &end, []string{march.Id}},
+		}
+		for _, test := range tests {
+			filter := models.BlogPostFilter{Search: "ranged", Status: models.STATUS_ALL, From: test.from, To: test.to}
+			data, _, err := postService.GetAll(ctx, filter, 10, 1)
+			assert.NoError(t, err)
+			ids := []string{}
+			for _, post := range data {
+				ids = append(ids, post.Id)
+			}
+			assert.Equal(t, test.ids, ids, test.name)
+
+			count, err := postService.Count(ctx, filter)
+			assert.NoError(t, err)
+			assert.Equal(t, len(test.ids), count, test.name)
+		}
+	})
+
+	t.Run("GetArchive success", func(t *testing.T) {
+		dates := []time.Time{
+			time.Date(2023, 12, 5, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		}
+		for i, date := range dates {
+			post, err := postService.Create(ctx, &models.BlogPostCreated{Title: "archived " + string(rune('a'+i)), CreatedAt: date})
+			assert.NoError(t, err)
+			defer func() {
+				_, err = postService.Remove(ctx, post.Id)
+				assert.NoError(t, err)
+			}()
+		}
+		draft, err := postService.Create(ctx, &models.BlogPostCreated{Title: "archived draft", CreatedAt: dates[0], IsDraft: true})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, draft.Id)
+			assert.NoError(t, err)
+		}()
+
+		// Drafts are left out of the published archive
+		data, err := postService.GetArchive(ctx, models.BlogPostFilter{Search: "archived"}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.BlogPostArchiveYear{
+			{Year: 2024, Count: 3, Months: []models.BlogPostArchiveMonth{{Month: 2, Count: 1}, {Month: 1, Count: 2}}},
+			{Year: 2023, Count: 1, Months: []models.BlogPostArchiveMonth{{Month: 12, Count: 1}}},
+		}, data)
+
+		data, err = postService.GetArchive(ctx, models.BlogPostFilter{Search: "archived", Status: models.STATUS_ALL}, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, data[1].Months[0].Count)
+		posts := data[0].Months[1].Posts
+		assert.Equal(t, 2, len(posts))
+		assert.Equal(t, "archived c", posts[0].Title)
+		assert.Equal(t, "archived-c", posts[0].Slug)
+	})
+
+	t.Run("Create success with repeated tag", func(t *testing.T) {
+		// The tag is linked once however many times it is sent
+		value, err := postService.Create(ctx, &models.BlogPostCreated{Title: "repeated tag", Tags: []models.BlogTag{tagValue1, tagValue1}})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.BlogTag{tagValue1}, value.Tags)
+
+		_, err = postService.Remove(ctx, value.Id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("GetRelated success", func(t *testing.T) {
+		tags := []models.BlogTag{}
+		for _, name := range []string{"related common", "related rare", "related other"} {
+			tag, err := tagService.Create(ctx, &models.BlogTag{Name: name})
+			assert.NoError(t, err)
+			tags = append(tags, tag)
+		}
+		common, rare, other := tags[0], tags[1], tags[2]
+
+		// The common tag is on 4 published posts and the rare one on 3
+		now := time.Now().UTC().Truncate(time.Second)
+		posts := map[string]models.BlogPostContentWithTags{}
+		for i, input := range []models.BlogPostCreated{
+			{Title: "related source", Tags: []models.BlogTag{common, rare}},
+			{Title: "related both", Tags: []models.BlogTag{common, rare}},
+			{Title: "related rare", Tags: []models.BlogTag{rare}},
+			{Title: "related common newer", Tags: []models.BlogTag{common}},
+			{Title: "related common older", Tags: []models.BlogTag{common}},
+			{Title: "related draft", Tags: []models.BlogTag{common, rare}, IsDraft: true},
+			{Title: "related other", Tags: []models.BlogTag{other}},
+		} {
+			input.CreatedAt = now.Add(-time.Duration(i) * time.Hour)
+			post, err := postService.Create(ctx, &input)
+			assert.NoError(t, err)
+			posts[input.Title] = post
+		}
+
+		// Sharing more and rarer tags ranks higher, newer posts win ties
+		data, err := postService.GetRelated(ctx, "related-source", models.STATUS_PUBLISHED, 0)
+		assert.NoError(t, err)
+		ids := []string{}
+		for _, post := range data {
+			ids = append(ids, post.Id)
+		}
+		assert.Equal(t, []string{
+			posts["related both"].Id,
+			posts["related rare"].Id,
+			posts["related common newer"].Id,
+			posts["related common older"].Id,
+		}, ids)
+		assert.Equal(t, 2, len(data[0].Tags))
+
+		data, err = postService.GetRelated(ctx, "related-source", models.STATUS_PUBLISHED, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(data))
+
+		// Drafts are only found with their status but never listed
+		_, err = postService.GetRelated(ctx, "related-draft", models.STATUS_PUBLISHED, 0)
+		assert.ErrorIs(t, err, ErrNotFound)
+		data, err = postService.GetRelated(ctx, "related-draft", models.STATUS_ALL, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, len(data))
+
+		data, err = postService.GetRelated(ctx, "related-other", models.STATUS_PUBLISHED, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, data)
+	})
+
+	t.Run("Old slug taken over by a new post", func(t *testing.T) {
+		renamed, err := postService.Create(ctx, &models.BlogPostCreated{Title: "slug before"})
+		assert.NoError(t, err)
+		renamed, err = postService.Update(ctx, &models.BlogPostUpdated{Id: renamed.Id, Title: "slug after"})
+		assert.NoError(t, err)
+
+		_, err = postService.GetWithSlug(ctx, "slug-before", models.STATUS_PUBLISHED)
+		assert.ErrorIs(t, err, ErrMoved)
+
+		// A post created with the old slug gets it back
+		created, err := postService.Create(ctx, &models.BlogPostCreated{Title: "slug before"})
+		assert.NoError(t, err)
+		data, err := postService.GetWithSlug(ctx, "slug-before", models.STATUS_PUBLISHED)
+		assert.NoError(t, err)
+		assert.Equal(t, created.Id, data.Id)
+
+		// Removed posts leave no old slug behind
+		_, err = postService.Remove(ctx, created.Id)
+		assert.NoError(t, err)
+		_, err = postService.Remove(ctx, renamed.Id)
+		assert.NoError(t, err)
+		_, err = postService.GetWithSlug(ctx, "slug-before", models.STATUS_ALL)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Remove tag unlinks posts", func(t *testing.T) {
+		value, err := postService.Create(ctx, &models.BlogPostCreated{
+			Title: "linked post",
+			Tags:  []models.BlogTag{tagValue1},
+		})
+		assert.NoError(t, err)
+
+		_, err = tagService.Remove(ctx, tagValue1.Id)
+		assert.NoError(t, err)
+
+		data, err := postService.GetWithSlug(ctx, value.Slug, models.STATUS_ALL)
+		assert.NoError(t, err)
+		assert.Empty(t, data.Tags)
+	})
+}
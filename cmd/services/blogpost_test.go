@@ -1,21 +1,27 @@
 package services
 
 import (
+	"api-chi/cmd/config"
 	"api-chi/cmd/models"
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gosimple/slug"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_BlogPostService(t *testing.T) {
+	ctx := context.Background()
 	id := ""
-	tagService := BlogTagService{}
-	err := tagService.Open()
-	assert.NoError(t, err)
-	defer tagService.Close()
-	postService := BlogPostService{}
+	database := openTestDatabase(t)
+	tagService := NewBlogTagService(database)
+	postService := NewBlogPostService(database)
 	tag1 := models.BlogTag{
 		Name: "website",
 	}
@@ -25,27 +31,22 @@ func Test_BlogPostService(t *testing.T) {
 	tag3 := models.BlogTag{
 		Name: "life",
 	}
-	tagValue1, err := tagService.Create(&tag1)
+	tagValue1, err := tagService.Create(ctx, &tag1)
 	assert.NoError(t, err)
-	tagValue2, err := tagService.Create(&tag2)
+	tagValue2, err := tagService.Create(ctx, &tag2)
 	assert.NoError(t, err)
-	tagValue3, err := tagService.Create(&tag3)
+	tagValue3, err := tagService.Create(ctx, &tag3)
 	assert.NoError(t, err)
 	defer func() {
-		_, err = tagService.Remove(tagValue1.Id)
+		_, err = tagService.Remove(ctx, tagValue1.Id)
 		assert.NoError(t, err)
-		_, err = tagService.Remove(tagValue2.Id)
+		_, err = tagService.Remove(ctx, tagValue2.Id)
 		assert.NoError(t, err)
-		_, err = tagService.Remove(tagValue3.Id)
+		_, err = tagService.Remove(ctx, tagValue3.Id)
 		assert.NoError(t, err)
 	}()
 
 	t.Run("Create success", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
-		assert.NoError(t, err)
-
 		// Declare input
 		tags := []models.BlogTag{tagValue1, tagValue2}
 		input := models.BlogPostCreated{
@@ -58,13 +59,16 @@ func Test_BlogPostService(t *testing.T) {
 		}
 
 		// Create post
-		value, err := postService.Create(&input)
+		value, err := postService.Create(ctx, &input)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, value)
 		assert.IsType(t, value, models.BlogPostContentWithTags{})
 		assert.Equal(t, value.Title, input.Title)
 		assert.Equal(t, value.Slug, slug.Make(input.Title))
 		assert.Equal(t, value.Content, input.Content)
+		assert.Equal(t, "Hello new post!", value.Excerpt)
+		assert.Equal(t, 3, value.WordCount)
+		assert.Equal(t, 1, value.ReadingTimeMinutes)
 		assert.WithinDuration(t, value.CreatedAt, input.CreatedAt, time.Millisecond)
 		assert.WithinDuration(t, value.UpdatedAt, input.UpdatedAt, time.Millisecond)
 		assert.Equal(t, value.IsDraft, input.IsDraft)
@@ -78,11 +82,6 @@ func Test_BlogPostService(t *testing.T) {
 	})
 
 	t.Run("Update success", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
-		assert.NoError(t, err)
-
 		// Declare input
 		tags := []models.BlogTag{tagValue1, tagValue3}
 		input := models.BlogPostUpdated{
@@ -96,7 +95,7 @@ func Test_BlogPostService(t *testing.T) {
 		}
 
 		// Update post
-		value, err := postService.Update(&input)
+		value, err := postService.Update(ctx, &input)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, value)
 		assert.IsType(t, value, models.BlogPostContentWithTags{})
@@ -112,24 +111,62 @@ func Test_BlogPostService(t *testing.T) {
 		}
 	})
 
-	t.Run("Remove success", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
+	t.Run("Update failed with invalid tag", func(t *testing.T) {
+		// Declare input with a tag that doesn't exist
+		invalidTag := models.BlogTag{Id: "00000000-0000-0000-0000-000000000000"}
+		input := models.BlogPostUpdated{
+			Id:        id,
+			Title:     "My invalid post",
+			Content:   "## Hello my invalid post!",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			IsDraft:   true,
+			Tags:      []models.BlogTag{tagValue2, invalidTag},
+		}
+
+		// Update post
+		_, err := postService.Update(ctx, &input)
+		invalidTagErr := &InvalidTagError{}
+		assert.ErrorAs(t, err, &invalidTagErr)
+		assert.Equal(t, invalidTag.Id, invalidTagErr.TagId)
+
+		// Nothing was written
+		data, err := postService.GetWithSlug(ctx, slug.Make("My test post"), models.STATUS_ALL)
 		assert.NoError(t, err)
+		assert.Equal(t, "My test post", data.Title)
+		assert.Equal(t, 2, len(data.Tags))
+	})
 
+	t.Run("Create failed with invalid tag", func(t *testing.T) {
+		// Declare input with a malformed tag id
+		input := models.BlogPostCreated{
+			Title:     "invalid tag post",
+			Content:   "## Hello invalid tag post!",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			IsDraft:   true,
+			Tags:      []models.BlogTag{tagValue1, {Id: "not-a-uuid"}},
+		}
+
+		// Create post
+		_, err := postService.Create(ctx, &input)
+		invalidTagErr := &InvalidTagError{}
+		assert.ErrorAs(t, err, &invalidTagErr)
+		assert.Equal(t, "not-a-uuid", invalidTagErr.TagId)
+
+		// The post was rolled back
+		_, err = postService.GetWithSlug(ctx, slug.Make(input.Title), models.STATUS_ALL)
+		assert.Error(t, err)
+	})
+
+	t.Run("Remove success", func(t *testing.T) {
 		// Remove post
-		value, err := postService.Remove(id)
+		value, err := postService.Remove(ctx, id)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, value)
 	})
 
 	t.Run("Get with slug success", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
-		assert.NoError(t, err)
-
 		// Create data
 		tagsPost := []models.BlogTag{tagValue1, tagValue2}
 		inputPost := models.BlogPostCreated{
@@ -140,14 +177,14 @@ func Test_BlogPostService(t *testing.T) {
 			IsDraft:   true,
 			Tags:      tagsPost,
 		}
-		valuePost, _ := postService.Create(&inputPost)
+		valuePost, _ := postService.Create(ctx, &inputPost)
 		defer func() {
-			_, err = postService.Remove(valuePost.Id)
+			_, err = postService.Remove(ctx, valuePost.Id)
 			assert.NoError(t, err)
 		}()
 
 		// Get all database
-		data, err := postService.GetWithSlug(valuePost.Slug)
+		data, err := postService.GetWithSlug(ctx, valuePost.Slug, models.STATUS_ALL)
 		assert.NoError(t, err)
 
 		assert.IsType(t, data, models.BlogPostContentWithTags{})
@@ -161,14 +198,14 @@ func Test_BlogPostService(t *testing.T) {
 			assert.NotEmpty(t, tag.Id)
 			assert.NotEmpty(t, tag.Name)
 		}
-	})
 
-	t.Run("GetAll default success", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
+		// Drafts are found by id as well
+		dataWithId, err := postService.GetWithId(ctx, valuePost.Id)
 		assert.NoError(t, err)
+		assert.Equal(t, data, dataWithId)
+	})
 
+	t.Run("GetAll default success", func(t *testing.T) {
 		// Create data
 		tagsPost1 := []models.BlogTag{tagValue1, tagValue2}
 		inputPost1 := models.BlogPostCreated{
@@ -188,12 +225,12 @@ func Test_BlogPostService(t *testing.T) {
 			IsDraft:   true,
 			Tags:      tagsPost2,
 		}
-		valuePost1, _ := postService.Create(&inputPost1)
-		valuePost2, _ := postService.Create(&inputPost2)
+		valuePost1, _ := postService.Create(ctx, &inputPost1)
+		valuePost2, _ := postService.Create(ctx, &inputPost2)
 		defer func() {
-			_, err = postService.Remove(valuePost1.Id)
+			_, err = postService.Remove(ctx, valuePost1.Id)
 			assert.NoError(t, err)
-			_, err = postService.Remove(valuePost2.Id)
+			_, err = postService.Remove(ctx, valuePost2.Id)
 			assert.NoError(t, err)
 		}()
 
@@ -204,8 +241,9 @@ func Test_BlogPostService(t *testing.T) {
 		page := 1
 
 		// Get all database
-		data, err := postService.GetAll(search, tagsSearch, limit, page)
+		data, meta, err := postService.GetAll(ctx, models.BlogPostFilter{Search: search, Tags: tagsSearch, Status: models.STATUS_ALL}, limit, page)
 		assert.NoError(t, err)
+		assert.Equal(t, models.PageMeta{Total: 2, Page: 1, Limit: 10, TotalPages: 1}, meta)
 
 		assert.IsType(t, data[0], models.BlogPostWithTags{})
 		count := 0
@@ -226,11 +264,6 @@ func Test_BlogPostService(t *testing.T) {
 	})
 
 	t.Run("GetAll success with search", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
-		assert.NoError(t, err)
-
 		// Create data
 		tagsPost1 := []models.BlogTag{tagValue1, tagValue2}
 		inputPost1 := models.BlogPostCreated{
@@ -250,12 +283,12 @@ func Test_BlogPostService(t *testing.T) {
 			IsDraft:   true,
 			Tags:      tagsPost2,
 		}
-		valuePost1, _ := postService.Create(&inputPost1)
-		valuePost2, _ := postService.Create(&inputPost2)
+		valuePost1, _ := postService.Create(ctx, &inputPost1)
+		valuePost2, _ := postService.Create(ctx, &inputPost2)
 		defer func() {
-			_, err = postService.Remove(valuePost1.Id)
+			_, err = postService.Remove(ctx, valuePost1.Id)
 			assert.NoError(t, err)
-			_, err = postService.Remove(valuePost2.Id)
+			_, err = postService.Remove(ctx, valuePost2.Id)
 			assert.NoError(t, err)
 		}()
 
@@ -266,7 +299,7 @@ func Test_BlogPostService(t *testing.T) {
 		page := 1
 
 		// Get all database
-		data, err := postService.GetAll(search, tagsSearch, limit, page)
+		data, _, err := postService.GetAll(ctx, models.BlogPostFilter{Search: search, Tags: tagsSearch, Status: models.STATUS_ALL}, limit, page)
 		assert.NoError(t, err)
 
 		assert.IsType(t, data[0], models.BlogPostWithTags{})
@@ -287,12 +320,77 @@ func Test_BlogPostService(t *testing.T) {
 		assert.Equal(t, count, 1)
 	})
 
-	t.Run("GetAll success with tags", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
+	t.Run("GetAll success with full text search", func(t *testing.T) {
+		// Create data
+		inputPost1 := models.BlogPostCreated{
+			Title:     "Channels in Go",
+			Content:   "Use select with channels. Channels are typed.",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		inputPost2 := models.BlogPostCreated{
+			Title:     "Mutex basics",
+			Content:   "A mutex guards shared state, channels are another way.",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		valuePost1, err := postService.Create(ctx, &inputPost1)
 		assert.NoError(t, err)
+		valuePost2, err := postService.Create(ctx, &inputPost2)
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, valuePost1.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, valuePost2.Id)
+			assert.NoError(t, err)
+		}()
+
+		tests := []struct {
+			name   string
+			search string
+			ids    []string
+		}{
+			{"ranked by matches", "channels", []string{valuePost1.Id, valuePost2.Id}},
+			{"with excluded word", "channels -mutex", []string{valuePost1.Id}},
+			{"with phrase", `"shared state"`, []string{valuePost2.Id}},
+			{"with or", "typed or guards shared", []string{valuePost2.Id, valuePost1.Id}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				data, _, err := postService.GetAll(ctx, models.BlogPostFilter{Search: test.search, Tags: []models.BlogTag{}, Status: models.STATUS_ALL}, 10, 1)
+				assert.NoError(t, err)
+				ids := []string{}
+				for _, post := range data {
+					ids = append(ids, post.Id)
+					assert.Contains(t, post.Snippet, "<mark>")
+				}
+				assert.Equal(t, test.ids, ids)
+
+				count, err := postService.Count(ctx, models.BlogPostFilter{Search: test.search, Tags: []models.BlogTag{}, Status: models.STATUS_ALL})
+				assert.NoError(t, err)
+				assert.Equal(t, len(test.ids), count)
+			})
+		}
 
+		// Snippets are only made when searching
+		data, _, err := postService.GetAllWithContent(ctx, models.BlogPostFilter{Tags: []models.BlogTag{}, Status: models.STATUS_ALL}, 10, 1)
+		assert.NoError(t, err)
+		for _, post := range data {
+			assert.Empty(t, post.Snippet)
+		}
+	})
+
+	t.Run("ReindexSearch success", func(t *testing.T) {
+		// Posts are indexed in the configured language when saved
+		_, err := postService.ReindexSearch(ctx)
+		assert.NoError(t, err)
+		count, err := postService.ReindexSearch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("GetAll success with tags", func(t *testing.T) {
 		// Create data
 		tagsPost1 := []models.BlogTag{tagValue1, tagValue2}
 		inputPost1 := models.BlogPostCreated{
@@ -312,12 +410,12 @@ func Test_BlogPostService(t *testing.T) {
 			IsDraft:   true,
 			Tags:      tagsPost2,
 		}
-		valuePost1, _ := postService.Create(&inputPost1)
-		valuePost2, _ := postService.Create(&inputPost2)
+		valuePost1, _ := postService.Create(ctx, &inputPost1)
+		valuePost2, _ := postService.Create(ctx, &inputPost2)
 		defer func() {
-			_, err = postService.Remove(valuePost1.Id)
+			_, err = postService.Remove(ctx, valuePost1.Id)
 			assert.NoError(t, err)
-			_, err = postService.Remove(valuePost2.Id)
+			_, err = postService.Remove(ctx, valuePost2.Id)
 			assert.NoError(t, err)
 		}()
 
@@ -331,7 +429,7 @@ func Test_BlogPostService(t *testing.T) {
 		page := 1
 
 		// Get all database
-		data, err := postService.GetAll(search, tagsSearch, limit, page)
+		data, _, err := postService.GetAll(ctx, models.BlogPostFilter{Search: search, Tags: tagsSearch, Status: models.STATUS_ALL}, limit, page)
 		assert.NoError(t, err)
 
 		assert.IsType(t, data[0], models.BlogPostWithTags{})
@@ -353,11 +451,6 @@ func Test_BlogPostService(t *testing.T) {
 	})
 
 	t.Run("GetAllWithContent default success", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
-		assert.NoError(t, err)
-
 		// Create data
 		tagsPost1 := []models.BlogTag{tagValue1, tagValue2}
 		inputPost1 := models.BlogPostCreated{
@@ -377,12 +470,12 @@ func Test_BlogPostService(t *testing.T) {
 			IsDraft:   true,
 			Tags:      tagsPost2,
 		}
-		valuePost1, _ := postService.Create(&inputPost1)
-		valuePost2, _ := postService.Create(&inputPost2)
+		valuePost1, _ := postService.Create(ctx, &inputPost1)
+		valuePost2, _ := postService.Create(ctx, &inputPost2)
 		defer func() {
-			_, err = postService.Remove(valuePost1.Id)
+			_, err = postService.Remove(ctx, valuePost1.Id)
 			assert.NoError(t, err)
-			_, err = postService.Remove(valuePost2.Id)
+			_, err = postService.Remove(ctx, valuePost2.Id)
 			assert.NoError(t, err)
 		}()
 
@@ -393,7 +486,7 @@ func Test_BlogPostService(t *testing.T) {
 		page := 1
 
 		// Get all database
-		data, err := postService.GetAllWithContent(search, tagsSearch, limit, page)
+		data, _, err := postService.GetAllWithContent(ctx, models.BlogPostFilter{Search: search, Tags: tagsSearch, Status: models.STATUS_ALL}, limit, page)
 		assert.NoError(t, err)
 
 		assert.IsType(t, data[0], models.BlogPostContentWithTags{})
@@ -416,11 +509,6 @@ func Test_BlogPostService(t *testing.T) {
 	})
 
 	t.Run("GetAll success with search", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
-		assert.NoError(t, err)
-
 		// Create data
 		tagsPost1 := []models.BlogTag{tagValue1, tagValue2}
 		inputPost1 := models.BlogPostCreated{
@@ -440,12 +528,12 @@ func Test_BlogPostService(t *testing.T) {
 			IsDraft:   true,
 			Tags:      tagsPost2,
 		}
-		valuePost1, _ := postService.Create(&inputPost1)
-		valuePost2, _ := postService.Create(&inputPost2)
+		valuePost1, _ := postService.Create(ctx, &inputPost1)
+		valuePost2, _ := postService.Create(ctx, &inputPost2)
 		defer func() {
-			_, err = postService.Remove(valuePost1.Id)
+			_, err = postService.Remove(ctx, valuePost1.Id)
 			assert.NoError(t, err)
-			_, err = postService.Remove(valuePost2.Id)
+			_, err = postService.Remove(ctx, valuePost2.Id)
 			assert.NoError(t, err)
 		}()
 
@@ -456,7 +544,7 @@ func Test_BlogPostService(t *testing.T) {
 		page := 1
 
 		// Get all database
-		data, err := postService.GetAllWithContent(search, tagsSearch, limit, page)
+		data, _, err := postService.GetAllWithContent(ctx, models.BlogPostFilter{Search: search, Tags: tagsSearch, Status: models.STATUS_ALL}, limit, page)
 		assert.NoError(t, err)
 
 		assert.IsType(t, data[0], models.BlogPostContentWithTags{})
@@ -479,11 +567,6 @@ func Test_BlogPostService(t *testing.T) {
 	})
 
 	t.Run("GetAll success with tags", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
-		assert.NoError(t, err)
-
 		// Create data
 		tagsPost1 := []models.BlogTag{tagValue1, tagValue2}
 		inputPost1 := models.BlogPostCreated{
@@ -503,12 +586,12 @@ func Test_BlogPostService(t *testing.T) {
 			IsDraft:   true,
 			Tags:      tagsPost2,
 		}
-		valuePost1, _ := postService.Create(&inputPost1)
-		valuePost2, _ := postService.Create(&inputPost2)
+		valuePost1, _ := postService.Create(ctx, &inputPost1)
+		valuePost2, _ := postService.Create(ctx, &inputPost2)
 		defer func() {
-			_, err = postService.Remove(valuePost1.Id)
+			_, err = postService.Remove(ctx, valuePost1.Id)
 			assert.NoError(t, err)
-			_, err = postService.Remove(valuePost2.Id)
+			_, err = postService.Remove(ctx, valuePost2.Id)
 			assert.NoError(t, err)
 		}()
 
@@ -522,7 +605,7 @@ func Test_BlogPostService(t *testing.T) {
 		page := 1
 
 		// Get all database
-		data, err := postService.GetAllWithContent(search, tagsSearch, limit, page)
+		data, _, err := postService.GetAllWithContent(ctx, models.BlogPostFilter{Search: search, Tags: tagsSearch, Status: models.STATUS_ALL}, limit, page)
 		assert.NoError(t, err)
 
 		assert.IsType(t, data[0], models.BlogPostContentWithTags{})
@@ -544,12 +627,283 @@ func Test_BlogPostService(t *testing.T) {
 		assert.Equal(t, count, 1)
 	})
 
-	t.Run("Count success", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
+	t.Run("Count matches GetAll with tag modes", func(t *testing.T) {
+		// Create data
+		inputPost1 := models.BlogPostCreated{
+			Title:     "new post",
+			Content:   "## Hello new post!",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Tags:      []models.BlogTag{tagValue1, tagValue2},
+		}
+		inputPost2 := models.BlogPostCreated{
+			Title:     "My test post",
+			Content:   "## Hello my test post!",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Tags:      []models.BlogTag{tagValue2, tagValue3},
+		}
+		valuePost1, err := postService.Create(ctx, &inputPost1)
+		assert.NoError(t, err)
+		valuePost2, err := postService.Create(ctx, &inputPost2)
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, valuePost1.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, valuePost2.Id)
+			assert.NoError(t, err)
+		}()
+
+		tests := []struct {
+			name    string
+			tags    []models.BlogTag
+			tagMode string
+			exclude []models.BlogTag
+			ids     []string
+		}{
+			{"with all tags", []models.BlogTag{tagValue1, tagValue2}, models.TAG_MODE_ALL, nil, []string{valuePost1.Id}},
+			{"with any tag", []models.BlogTag{tagValue1, tagValue3}, models.TAG_MODE_ANY, nil, []string{valuePost1.Id, valuePost2.Id}},
+			{"with shared tag", []models.BlogTag{tagValue2}, models.TAG_MODE_ANY, nil, []string{valuePost1.Id, valuePost2.Id}},
+			{"with excluded tag", []models.BlogTag{tagValue2}, "", []models.BlogTag{tagValue1}, []string{valuePost2.Id}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				filter := models.BlogPostFilter{Tags: test.tags, TagMode: test.tagMode, ExcludeTags: test.exclude, Status: models.STATUS_ALL}
+				data, _, err := postService.GetAll(ctx, filter, 10, 1)
+				assert.NoError(t, err)
+				ids := []string{}
+				for _, post := range data {
+					ids = append(ids, post.Id)
+				}
+				assert.ElementsMatch(t, test.ids, ids)
+
+				// Posts matching several tags are counted once
+				count, err := postService.Count(ctx, filter)
+				assert.NoError(t, err)
+				assert.Equal(t, len(test.ids), count)
+			})
+		}
+	})
+
+	t.Run("GetAll success with sort", func(t *testing.T) {
+		// Create data
+		now := time.Now()
+		first, err := postService.Create(ctx, &models.BlogPostCreated{Title: "sorted b", CreatedAt: now.Add(-time.Hour), UpdatedAt: now})
+		assert.NoError(t, err)
+		second, err := postService.Create(ctx, &models.BlogPostCreated{Title: "sorted a", CreatedAt: now, UpdatedAt: now.Add(-time.Hour)})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, first.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, second.Id)
+			assert.NoError(t, err)
+		}()
+
+		tests := []struct {
+			name string
+			sort models.Sort
+			ids  []string
+		}{
+			{"most relevant then newest", models.Sort{}, []string{second.Id, first.Id}},
+			{"oldest first", models.Sort{Field: models.SORT_CREATED_AT, Order: models.ORDER_ASC}, []string{first.Id, second.Id}},
+			{"updated", models.Sort{Field: models.SORT_UPDATED_AT}, []string{first.Id, second.Id}},
+			{"title", models.Sort{Field: models.SORT_TITLE}, []string{second.Id, first.Id}},
+			{"title descending", models.Sort{Field: models.SORT_TITLE, Order: models.ORDER_DESC}, []string{first.Id, second.Id}},
+		}
+		for _, test := range tests {
+			filter := models.BlogPostFilter{Search: "sorted", Status: models.STATUS_ALL, Sort: test.sort}
+			data, _, err := postService.GetAll(ctx, filter, 10, 1)
+			assert.NoError(t, err)
+			ids := []string{}
+			for _, post := range data {
+				ids = append(ids, post.Id)
+			}
+			assert.Equal(t, test.ids, ids, test.name)
+		}
+	})
+
+	t.Run("GetAllWithCursor success", func(t *testing.T) {
+		// Create more posts than one page holds, two of them at the same time
+		now := time.Now()
+		for i := range 12 {
+			post, err := postService.Create(ctx, &models.BlogPostCreated{Title: "cursor post " + string(rune('a'+i)), CreatedAt: now.Add(time.Duration(min(i, 10)) * time.Minute)})
+			assert.NoError(t, err)
+			defer func() {
+				_, err = postService.Remove(ctx, post.Id)
+				assert.NoError(t, err)
+			}()
+		}
+
+		for _, sort := range []models.Sort{{}, {Field: models.SORT_CREATED_AT}, {Field: models.SORT_TITLE}} {
+			filter := models.BlogPostFilter{Search: "cursor", Status: models.STATUS_ALL, Sort: sort}
+			all, _, err := postService.GetAll(ctx, filter, 50, 1)
+			assert.NoError(t, err)
+
+			// Walk forward then back to the first page
+			first, cursors, err := postService.GetAllWithCursor(ctx, filter, nil, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, all[:10], first, sort.Field)
+			assert.Empty(t, cursors.Prev)
+
+			next, err := models.DecodeCursor(cursors.Next)
+			assert.NoError(t, err)
+			second, cursors, err := postService.GetAllWithCursor(ctx, filter, &next, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, all[10:], second, sort.Field)
+			assert.Empty(t, cursors.Next)
+
+			prev, err := models.DecodeCursor(cursors.Prev)
+			assert.NoError(t, err)
+			data, cursors, err := postService.GetAllWithCursor(ctx, filter, &prev, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, first, data, sort.Field)
+			assert.Empty(t, cursors.Prev)
+		}
+
+		// Keys Postgres can't cast are refused
+		filter := models.BlogPostFilter{Status: models.STATUS_ALL}
+		_, _, err := postService.GetAllWithCursor(ctx, filter, &models.Cursor{Keys: []string{"yesterday", "0"}}, 10)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		_, _, err = postService.GetAllWithCursor(ctx, filter, &models.Cursor{Keys: []string{}}, 10)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("GetAll and Count success with date range", func(t *testing.T) {
+		march, err := postService.Create(ctx, &models.BlogPostCreated{Title: "ranged march", CreatedAt: time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)})
+		assert.NoError(t, err)
+		april, err := postService.Create(ctx, &models.BlogPostCreated{Title: "ranged april", CreatedAt: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, march.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, april.Id)
+			assert.NoError(t, err)
+		}()
+
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+		filter := models.BlogPostFilter{Search: "ranged", Status: models.STATUS_ALL, From: &start, To: &end}
+		data, meta, err := postService.GetAll(ctx, filter, 10, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(data))
+		assert.Equal(t, march.Id, data[0].Id)
+		assert.Equal(t, 1, meta.Total)
+
+		count, err := postService.Count(ctx, models.BlogPostFilter{Search: "ranged", Status: models.STATUS_ALL, From: &end})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("GetArchive success", func(t *testing.T) {
+		dates := []time.Time{
+			time.Date(2023, 12, 5, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		}
+		for i, date := range dates {
+			post, err := postService.Create(ctx, &models.BlogPostCreated{Title: "archived " + string(rune('a'+i)), CreatedAt: date, IsDraft: i == 0})
+			assert.NoError(t, err)
+			defer func() {
+				_, err = postService.Remove(ctx, post.Id)
+				assert.NoError(t, err)
+			}()
+		}
+
+		// Drafts are left out of the published archive
+		data, err := postService.GetArchive(ctx, models.BlogPostFilter{Search: "archived"}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.BlogPostArchiveYear{
+			{Year: 2024, Count: 3, Months: []models.BlogPostArchiveMonth{{Month: 2, Count: 1}, {Month: 1, Count: 2}}},
+		}, data)
+
+		data, err = postService.GetArchive(ctx, models.BlogPostFilter{Search: "archived", Status: models.STATUS_ALL}, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(data))
+		posts := data[0].Months[1].Posts
+		assert.Equal(t, 2, len(posts))
+		assert.Equal(t, "archived c", posts[0].Title)
+		assert.Equal(t, "archived-c", posts[0].Slug)
+	})
+
+	t.Run("Create success with repeated tag", func(t *testing.T) {
+		// The tag is linked once however many times it is sent
+		value, err := postService.Create(ctx, &models.BlogPostCreated{Title: "repeated tag", Tags: []models.BlogTag{tagValue1, tagValue1}})
+		assert.NoError(t, err)
+		assert.Equal(t, []models.BlogTag{tagValue1}, value.Tags)
+
+		_, err = postService.Remove(ctx, value.Id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("GetRelated success", func(t *testing.T) {
+		tags := []models.BlogTag{}
+		for _, name := range []string{"related common", "related rare", "related other"} {
+			tag, err := tagService.Create(ctx, &models.BlogTag{Name: name})
+			assert.NoError(t, err)
+			defer func() {
+				_, err = tagService.Remove(ctx, tag.Id)
+				assert.NoError(t, err)
+			}()
+			tags = append(tags, tag)
+		}
+		common, rare, other := tags[0], tags[1], tags[2]
+
+		// The common tag is on 4 published posts and the rare one on 3
+		now := time.Now().UTC().Truncate(time.Second)
+		posts := map[string]models.BlogPostContentWithTags{}
+		for i, input := range []models.BlogPostCreated{
+			{Title: "related source", Tags: []models.BlogTag{common, rare}},
+			{Title: "related both", Tags: []models.BlogTag{common, rare}},
+			{Title: "related rare", Tags: []models.BlogTag{rare}},
+			{Title: "related common newer", Tags: []models.BlogTag{common}},
+			{Title: "related common older", Tags: []models.BlogTag{common}},
+			{Title: "related draft", Tags: []models.BlogTag{common, rare}, IsDraft: true},
+			{Title: "related other", Tags: []models.BlogTag{other}},
+		} {
+			input.CreatedAt = now.Add(-time.Duration(i) * time.Hour)
+			post, err := postService.Create(ctx, &input)
+			assert.NoError(t, err)
+			defer func() {
+				_, err = postService.Remove(ctx, post.Id)
+				assert.NoError(t, err)
+			}()
+			posts[input.Title] = post
+		}
+
+		// Sharing more and rarer tags ranks higher, newer posts win ties
+		data, err := postService.GetRelated(ctx, "related-source", models.STATUS_PUBLISHED, 0)
+		assert.NoError(t, err)
+		ids := []string{}
+		for _, post := range data {
+			ids = append(ids, post.Id)
+		}
+		assert.Equal(t, []string{
+			posts["related both"].Id,
+			posts["related rare"].Id,
+			posts["related common newer"].Id,
+			posts["related common older"].Id,
+		}, ids)
+		assert.Equal(t, 2, len(data[0].Tags))
+
+		data, err = postService.GetRelated(ctx, "related-source", models.STATUS_PUBLISHED, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(data))
+
+		// Drafts are only found with their status but never listed
+		_, err = postService.GetRelated(ctx, "related-draft", models.STATUS_PUBLISHED, 0)
+		assert.ErrorIs(t, err, ErrNotFound)
+		data, err = postService.GetRelated(ctx, "related-draft", models.STATUS_ALL, 0)
 		assert.NoError(t, err)
+		assert.Equal(t, 5, len(data))
 
+		data, err = postService.GetRelated(ctx, "related-other", models.STATUS_PUBLISHED, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, data)
+	})
+
+	t.Run("Count success", func(t *testing.T) {
 		// Create data
 		tagsPost1 := []models.BlogTag{tagValue1, tagValue2}
 		inputPost1 := models.BlogPostCreated{
@@ -569,12 +923,12 @@ func Test_BlogPostService(t *testing.T) {
 			IsDraft:   true,
 			Tags:      tagsPost2,
 		}
-		valuePost1, _ := postService.Create(&inputPost1)
-		valuePost2, _ := postService.Create(&inputPost2)
+		valuePost1, _ := postService.Create(ctx, &inputPost1)
+		valuePost2, _ := postService.Create(ctx, &inputPost2)
 		defer func() {
-			_, err = postService.Remove(valuePost1.Id)
+			_, err = postService.Remove(ctx, valuePost1.Id)
 			assert.NoError(t, err)
-			_, err = postService.Remove(valuePost2.Id)
+			_, err = postService.Remove(ctx, valuePost2.Id)
 			assert.NoError(t, err)
 		}()
 
@@ -583,17 +937,12 @@ func Test_BlogPostService(t *testing.T) {
 		tagsSearch := []models.BlogTag{}
 
 		// Count database
-		count, err := postService.Count(search, tagsSearch)
+		count, err := postService.Count(ctx, models.BlogPostFilter{Search: search, Tags: tagsSearch, Status: models.STATUS_ALL})
 		assert.NoError(t, err)
 		assert.Equal(t, count, 2)
 	})
 
 	t.Run("Count success with search", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
-		assert.NoError(t, err)
-
 		// Create data
 		tagsPost1 := []models.BlogTag{tagValue1, tagValue2}
 		inputPost1 := models.BlogPostCreated{
@@ -613,12 +962,12 @@ func Test_BlogPostService(t *testing.T) {
 			IsDraft:   true,
 			Tags:      tagsPost2,
 		}
-		valuePost1, _ := postService.Create(&inputPost1)
-		valuePost2, _ := postService.Create(&inputPost2)
+		valuePost1, _ := postService.Create(ctx, &inputPost1)
+		valuePost2, _ := postService.Create(ctx, &inputPost2)
 		defer func() {
-			_, err = postService.Remove(valuePost1.Id)
+			_, err = postService.Remove(ctx, valuePost1.Id)
 			assert.NoError(t, err)
-			_, err = postService.Remove(valuePost2.Id)
+			_, err = postService.Remove(ctx, valuePost2.Id)
 			assert.NoError(t, err)
 		}()
 
@@ -627,17 +976,12 @@ func Test_BlogPostService(t *testing.T) {
 		tagsSearch := []models.BlogTag{}
 
 		// Count database
-		count, err := postService.Count(search, tagsSearch)
+		count, err := postService.Count(ctx, models.BlogPostFilter{Search: search, Tags: tagsSearch, Status: models.STATUS_ALL})
 		assert.NoError(t, err)
 		assert.Equal(t, count, 1)
 	})
 
 	t.Run("Count success with tags", func(t *testing.T) {
-		// Connect database
-		err := postService.Open()
-		defer postService.Close()
-		assert.NoError(t, err)
-
 		// Create data
 		tagsPost1 := []models.BlogTag{tagValue1, tagValue2}
 		inputPost1 := models.BlogPostCreated{
@@ -657,12 +1001,12 @@ func Test_BlogPostService(t *testing.T) {
 			IsDraft:   true,
 			Tags:      tagsPost2,
 		}
-		valuePost1, _ := postService.Create(&inputPost1)
-		valuePost2, _ := postService.Create(&inputPost2)
+		valuePost1, _ := postService.Create(ctx, &inputPost1)
+		valuePost2, _ := postService.Create(ctx, &inputPost2)
 		defer func() {
-			_, err = postService.Remove(valuePost1.Id)
+			_, err = postService.Remove(ctx, valuePost1.Id)
 			assert.NoError(t, err)
-			_, err = postService.Remove(valuePost2.Id)
+			_, err = postService.Remove(ctx, valuePost2.Id)
 			assert.NoError(t, err)
 		}()
 
@@ -673,8 +1017,193 @@ func Test_BlogPostService(t *testing.T) {
 		}
 
 		// Count database
-		count, err := postService.Count(search, tagsSearch)
+		count, err := postService.Count(ctx, models.BlogPostFilter{Search: search, Tags: tagsSearch, Status: models.STATUS_ALL})
 		assert.NoError(t, err)
 		assert.Equal(t, count, 1)
 	})
+
+	t.Run("Create success with colliding title", func(t *testing.T) {
+		first, err := postService.Create(ctx, &models.BlogPostCreated{Title: "colliding post"})
+		assert.NoError(t, err)
+		second, err := postService.Create(ctx, &models.BlogPostCreated{Title: "Colliding post"})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, first.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, second.Id)
+			assert.NoError(t, err)
+		}()
+		assert.Equal(t, "colliding-post", first.Slug)
+		assert.Equal(t, "colliding-post-2", second.Slug)
+
+		// Saving again keeps the number
+		second, err = postService.Update(ctx, &models.BlogPostUpdated{Id: second.Id, Title: "Colliding post"})
+		assert.NoError(t, err)
+		assert.Equal(t, "colliding-post-2", second.Slug)
+
+		// Custom slugs are not numbered
+		_, err = postService.Create(ctx, &models.BlogPostCreated{Title: "custom post", Slug: "colliding-post"})
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+
+	t.Run("Update success keeps custom slug", func(t *testing.T) {
+		value, err := postService.Create(ctx, &models.BlogPostCreated{Title: "kept title", Slug: "kept-custom-slug"})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, value.Id)
+			assert.NoError(t, err)
+		}()
+
+		// Updates without a slug don't replace a custom one with the title
+		value, err = postService.Update(ctx, &models.BlogPostUpdated{Id: value.Id, Title: "kept title changed"})
+		assert.NoError(t, err)
+		assert.Equal(t, "kept-custom-slug", value.Slug)
+	})
+
+	t.Run("Get with old slug moved", func(t *testing.T) {
+		renamed, err := postService.Create(ctx, &models.BlogPostCreated{Title: "slug before"})
+		assert.NoError(t, err)
+		renamed, err = postService.Update(ctx, &models.BlogPostUpdated{Id: renamed.Id, Title: "slug after"})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, renamed.Id)
+			assert.NoError(t, err)
+		}()
+
+		// The old slug points at the current one
+		_, err = postService.GetWithSlug(ctx, "slug-before", models.STATUS_PUBLISHED)
+		moved := &MovedError{}
+		assert.ErrorAs(t, err, &moved)
+		assert.Equal(t, "slug-after", moved.Slug)
+
+		// A post created with the old slug gets it back
+		created, err := postService.Create(ctx, &models.BlogPostCreated{Title: "slug before"})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, created.Id)
+			assert.NoError(t, err)
+		}()
+		data, err := postService.GetWithSlug(ctx, "slug-before", models.STATUS_PUBLISHED)
+		assert.NoError(t, err)
+		assert.Equal(t, created.Id, data.Id)
+	})
+
+	t.Run("PublishDue success", func(t *testing.T) {
+		// Create data
+		past := time.Now().Add(-time.Minute)
+		future := time.Now().Add(time.Hour)
+		duePost, err := postService.Create(ctx, &models.BlogPostCreated{Title: "due post", IsDraft: true, PublishAt: &past})
+		assert.NoError(t, err)
+		laterPost, err := postService.Create(ctx, &models.BlogPostCreated{Title: "later post", IsDraft: true, PublishAt: &future})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = postService.Remove(ctx, duePost.Id)
+			assert.NoError(t, err)
+			_, err = postService.Remove(ctx, laterPost.Id)
+			assert.NoError(t, err)
+		}()
+
+		// Both posts are scheduled
+		count, err := postService.Count(ctx, models.BlogPostFilter{Search: "post", Status: models.STATUS_SCHEDULED})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		// Publish due posts
+		ids, err := postService.PublishDue(ctx, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{duePost.Id}, ids)
+
+		data, err := postService.GetWithSlug(ctx, duePost.Slug, models.STATUS_PUBLISHED)
+		assert.NoError(t, err)
+		assert.False(t, data.IsDraft)
+		assert.WithinDuration(t, past, *data.PublishAt, time.Millisecond)
+		_, err = postService.GetWithSlug(ctx, laterPost.Slug, models.STATUS_PUBLISHED)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+// queryCounter is a pgx tracer counting every query sent to the database
+type queryCounter struct {
+	count atomic.Int64
+}
+
+func (c *queryCounter) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	c.count.Add(1)
+	return ctx
+}
+
+func (c *queryCounter) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+func Benchmark_BlogPostService_GetAll(b *testing.B) {
+	ctx := context.Background()
+	skipWithoutDatabase(b)
+
+	// Open a pool that counts queries
+	counter := &queryCounter{}
+	poolConfig, err := pgxpool.ParseConfig(os.Getenv("POSTGRES_URL"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	poolConfig.ConnConfig.Tracer = counter
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer pool.Close()
+	database := &DatabaseService{Pool: pool, SearchLanguage: config.SEARCH_LANGUAGE}
+	tagService := NewBlogTagService(database)
+	postService := NewBlogPostService(database)
+
+	// Create 50 posts with two tags each
+	tagValue1, err := tagService.Create(ctx, &models.BlogTag{Name: "benchmark tag 1"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	tagValue2, err := tagService.Create(ctx, &models.BlogTag{Name: "benchmark tag 2"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	postIds := []string{}
+	for i := range 50 {
+		value, err := postService.Create(ctx, &models.BlogPostCreated{
+			Title:     fmt.Sprintf("benchmark post %d", i),
+			Content:   "## Hello benchmark post!",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Tags:      []models.BlogTag{tagValue1, tagValue2},
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		postIds = append(postIds, value.Id)
+	}
+	defer func() {
+		for _, id := range postIds {
+			_, _ = postService.Remove(ctx, id)
+		}
+		_, _ = tagService.Remove(ctx, tagValue1.Id)
+		_, _ = tagService.Remove(ctx, tagValue2.Id)
+	}()
+
+	// The number of queries must not grow with the page size
+	queriesPerPage := map[int]float64{}
+	for _, limit := range []int{10, 50} {
+		b.Run(fmt.Sprintf("limit %d", limit), func(b *testing.B) {
+			counter.count.Store(0)
+			for range b.N {
+				data, _, err := postService.GetAll(ctx, models.BlogPostFilter{Search: "benchmark", Tags: []models.BlogTag{}, Status: models.STATUS_ALL}, limit, 1)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(data) != limit {
+					b.Fatalf("expected %d posts, got %d", limit, len(data))
+				}
+			}
+			queriesPerPage[limit] = float64(counter.count.Load()) / float64(b.N)
+			b.ReportMetric(queriesPerPage[limit], "queries/op")
+		})
+	}
+	if queriesPerPage[10] != queriesPerPage[50] {
+		b.Fatalf("query count depends on page size: %v", queriesPerPage)
+	}
 }
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type BlogPostRevisionService struct {
+	Conn *DatabaseService
+}
+
+func NewBlogPostRevisionService(conn *DatabaseService) *BlogPostRevisionService {
+	return &BlogPostRevisionService{Conn: conn}
+}
+
+func (s *BlogPostRevisionService) GetAll(ctx context.Context, postId string) ([]models.BlogPostRevision, error) {
+	// Execute SQL, newest revision first
+	sql := `
+		SELECT id, post_id, number, title, created_at
+		FROM blog_post_revision
+		WHERE post_id = @post_id
+		ORDER BY number DESC;
+	`
+	value := []models.BlogPostRevision{}
+	rows, err := s.Conn.Query(ctx, sql, pgx.NamedArgs{"post_id": postId})
+	if err != nil {
+		return value, databaseError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := models.BlogPostRevision{}
+		if err := rows.Scan(&item.Id, &item.PostId, &item.Number, &item.Title, &item.CreatedAt); err != nil {
+			return value, databaseError(err)
+		}
+		value = append(value, item)
+	}
+	if err := rows.Err(); err != nil {
+		return value, databaseError(err)
+	}
+
+	return value, nil
+}
+
+func (s *BlogPostRevisionService) Get(ctx context.Context, postId string, id string) (models.BlogPostRevisionContent, error) {
+	// Execute SQL
+	sql := `
+		SELECT id, post_id, number, title, content, created_at
+		FROM blog_post_revision
+		WHERE id = @id AND post_id = @post_id;
+	`
+	args := pgx.NamedArgs{
+		"id":      id,
+		"post_id": postId,
+	}
+	value := models.BlogPostRevisionContent{}
+	err := s.Conn.QueryRow(ctx, sql, args).Scan(
+		&value.Id,
+		&value.PostId,
+		&value.Number,
+		&value.Title,
+		&value.Content,
+		&value.CreatedAt,
+	)
+	if err != nil {
+		return value, databaseError(err)
+	}
+
+	return value, nil
+}
+
+// createRevision saves the current title and content of a post inside tx,
+// before an update overwrites them. The post row stays locked until tx ends
+// so that concurrent updates get distinct revision numbers.
+func createRevision(ctx context.Context, tx pgx.Tx, postId string) error {
+	lockSql := "SELECT id FROM blog_post WHERE id = @id FOR UPDATE;"
+	if err := tx.QueryRow(ctx, lockSql, pgx.NamedArgs{"id": postId}).Scan(&postId); err != nil {
+		return err
+	}
+
+	revisionSql := `
+		INSERT INTO blog_post_revision (post_id, number, title, content)
+		SELECT
+			blog_post.id,
+			COALESCE((SELECT MAX(number) FROM blog_post_revision WHERE post_id = blog_post.id), 0) + 1,
+			blog_post.title,
+			blog_post.content
+		FROM blog_post
+		WHERE blog_post.id = @id;
+	`
+	_, err := tx.Exec(ctx, revisionSql, pgx.NamedArgs{"id": postId})
+	return err
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"context"
+)
+
+type MemoryBlogPostRevisionService struct {
+	Store *MemoryStore
+}
+
+func NewMemoryBlogPostRevisionService(store *MemoryStore) *MemoryBlogPostRevisionService {
+	return &MemoryBlogPostRevisionService{Store: store}
+}
+
+func (s *MemoryBlogPostRevisionService) GetAll(ctx context.Context, postId string) ([]models.BlogPostRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	// Revisions are kept oldest first, return the newest first
+	value := []models.BlogPostRevision{}
+	for i := len(s.Store.revisions) - 1; i >= 0; i-- {
+		revision := s.Store.revisions[i]
+		if revision.PostId != postId {
+			continue
+		}
+		value = append(value, models.BlogPostRevision{
+			Id:        revision.Id,
+			PostId:    revision.PostId,
+			Number:    revision.Number,
+			Title:     revision.Title,
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+	return value, nil
+}
+
+func (s *MemoryBlogPostRevisionService) Get(ctx context.Context, postId string, id string) (models.BlogPostRevisionContent, error) {
+	if err := ctx.Err(); err != nil {
+		return models.BlogPostRevisionContent{}, err
+	}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	for _, revision := range s.Store.revisions {
+		if revision.Id == id && revision.PostId == postId {
+			return revision, nil
+		}
+	}
+	return models.BlogPostRevisionContent{}, ErrNotFound
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BlogPostRevisionService(t *testing.T) {
+	ctx := context.Background()
+	database := openTestDatabase(t)
+	postService := NewBlogPostService(database)
+	revisionService := NewBlogPostRevisionService(database)
+
+	post, err := postService.Create(ctx, &models.BlogPostCreated{Title: "revision first title", Content: "first content"})
+	assert.NoError(t, err)
+	defer func() {
+		_, err = postService.Remove(ctx, post.Id)
+		assert.NoError(t, err)
+	}()
+
+	t.Run("Update creates revisions", func(t *testing.T) {
+		_, err := postService.Update(ctx, &models.BlogPostUpdated{Id: post.Id, Title: "revision second title", Content: "second content"})
+		assert.NoError(t, err)
+		_, err = postService.Update(ctx, &models.BlogPostUpdated{Id: post.Id, Title: "revision third title", Content: "third content"})
+		assert.NoError(t, err)
+
+		// Newest revision first, each one holds what the update replaced
+		data, err := revisionService.GetAll(ctx, post.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(data))
+		assert.Equal(t, 2, data[0].Number)
+		assert.Equal(t, "revision second title", data[0].Title)
+		assert.Equal(t, 1, data[1].Number)
+		assert.Equal(t, "revision first title", data[1].Title)
+
+		revision, err := revisionService.Get(ctx, post.Id, data[1].Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "first content", revision.Content)
+	})
+
+	t.Run("Update failed with unknown post", func(t *testing.T) {
+		_, err := postService.Update(ctx, &models.BlogPostUpdated{Id: "00000000-0000-4000-8000-000000000000", Title: "unknown post"})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Get failed with another post", func(t *testing.T) {
+		data, err := revisionService.GetAll(ctx, post.Id)
+		assert.NoError(t, err)
+
+		_, err = revisionService.Get(ctx, "00000000-0000-4000-8000-000000000000", data[0].Id)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"context"
+	"errors"
+
+	"github.com/gosimple/slug"
+	"github.com/jackc/pgx/v5"
+)
+
+type BlogSeriesService struct {
+	Conn *DatabaseService
+}
+
+func NewBlogSeriesService(conn *DatabaseService) *BlogSeriesService {
+	return &BlogSeriesService{Conn: conn}
+}
+
+// baseSeriesSlug returns the slug made from the title of a series
+func baseSeriesSlug(title string) string {
+	value := slug.Make(title)
+	if value == "" {
+		return "series"
+	}
+	return value
+}
+
+// seriesContext returns where the post is among the parts of its series,
+// nil when it isn't one of them
+func seriesContext(series models.BlogSeries, posts []models.BlogSeriesPost, postId string) *models.BlogPostSeries {
+	for i, post := range posts {
+		if post.Id != postId {
+			continue
+		}
+
+		value := &models.BlogPostSeries{
+			Id:    series.Id,
+			Title: series.Title,
+			Slug:  series.Slug,
+			Part:  i + 1,
+			Total: len(posts),
+		}
+		if i > 0 {
+			value.Prev = &posts[i-1]
+		}
+		if i < len(posts)-1 {
+			value.Next = &posts[i+1]
+		}
+		return value
+	}
+	return nil
+}
+
+// seriesPosts returns the parts of a series with the status, in order
+func seriesPosts(ctx context.Context, q querier, seriesId string, status string) ([]models.BlogSeriesPost, error) {
+	sql := `
+		SELECT blog_post.id, blog_post.title, blog_post.slug, blog_series_post.position
+		FROM blog_series_post
+		INNER JOIN blog_post ON blog_post.id = blog_series_post.post_id
+		WHERE blog_series_post.series_id = @series_id` + statusCondition(status) + `
+		ORDER BY blog_series_post.position;
+	`
+	value := []models.BlogSeriesPost{}
+	rows, err := q.Query(ctx, sql, pgx.NamedArgs{"series_id": seriesId})
+	if err != nil {
+		return value, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := models.BlogSeriesPost{}
+		if err := rows.Scan(&item.Id, &item.Title, &item.Slug, &item.Position); err != nil {
+			return value, err
+		}
+		value = append(value, item)
+	}
+	return value, rows.Err()
+}
+
+// postSeries returns the series of a post with the parts having the status
+// around it, nil when the post isn't part of a series
+func postSeries(ctx context.Context, q querier, postId string, status string) (*models.BlogPostSeries, error) {
+	sql := `
+		SELECT blog_series.id, blog_series.title, blog_series.slug
+		FROM blog_series
+		INNER JOIN blog_series_post ON blog_series_post.series_id = blog_series.id
+		WHERE blog_series_post.post_id = @post_id;
+	`
+	series := models.BlogSeries{}
+	err := q.QueryRow(ctx, sql, pgx.NamedArgs{"post_id": postId}).Scan(&series.Id, &series.Title, &series.Slug)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := seriesPosts(ctx, q, series.Id, status)
+	if err != nil {
+		return nil, err
+	}
+	return seriesContext(series, posts, postId), nil
+}
+
+func (s *BlogSeriesService) GetAll(ctx context.Context) ([]models.BlogSeries, error) {
+	// Execute SQL
+	sql := "SELECT id, title, slug, description FROM blog_series ORDER BY title, id;"
+	value := []models.BlogSeries{}
+	rows, err := s.Conn.Query(ctx, sql)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := models.BlogSeries{}
+		if err := rows.Scan(&item.Id, &item.Title, &item.Slug, &item.Description); err != nil {
+			return value, databaseError(err)
+		}
+		value = append(value, item)
+	}
+	return value, databaseError(rows.Err())
+}
+
+// GetWithSlug returns a series with its parts having the status
+func (s *BlogSeriesService) GetWithSlug(ctx context.Context, slug string, status string) (models.BlogSeriesWithPosts, error) {
+	value, err := s.getSeries(ctx, s.Conn, "slug = @slug", pgx.NamedArgs{"slug": slug}, status)
+	return value, databaseError(err)
+}
+
+// getSeries returns the series matching the condition with its parts
+// having the status
+func (s *BlogSeriesService) getSeries(ctx context.Context, q querier, condition string, args pgx.NamedArgs, status string) (models.BlogSeriesWithPosts, error) {
+	sql := "SELECT id, title, slug, description FROM blog_series WHERE " + condition + ";"
+	value := models.BlogSeriesWithPosts{}
+	err := q.QueryRow(ctx, sql, args).Scan(&value.Id, &value.Title, &value.Slug, &value.Description)
+	if err != nil {
+		return value, err
+	}
+
+	value.Posts, err = seriesPosts(ctx, q, value.Id, status)
+	return value, err
+}
+
+func (s *BlogSeriesService) Create(ctx context.Context, input *models.BlogSeriesCreated) (models.BlogSeriesWithPosts, error) {
+	// Series and its parts are written in one transaction
+	value := models.BlogSeriesWithPosts{}
+	tx, err := s.Conn.Begin(ctx)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	slugString, err := chooseSeriesSlug(ctx, tx, input.Title, input.Slug, "")
+	if err != nil {
+		return value, databaseError(err)
+	}
+
+	// Create series
+	sql := "INSERT INTO blog_series (title, slug, description) VALUES (@title, @slug, @description) RETURNING id;"
+	args := pgx.NamedArgs{
+		"title":       input.Title,
+		"slug":        slugString,
+		"description": input.Description,
+	}
+	id := ""
+	if err := tx.QueryRow(ctx, sql, args).Scan(&id); err != nil {
+		return value, databaseError(err)
+	}
+	if err := setSeriesParts(ctx, tx, id, input.Parts); err != nil {
+		return value, databaseError(err)
+	}
+
+	value, err = s.getSeries(ctx, tx, "id = @id", pgx.NamedArgs{"id": id}, models.STATUS_ALL)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	return value, databaseError(tx.Commit(ctx))
+}
+
+func (s *BlogSeriesService) Update(ctx context.Context, input *models.BlogSeriesUpdated) (models.BlogSeriesWithPosts, error) {
+	// Series and its parts are written in one transaction
+	value := models.BlogSeriesWithPosts{}
+	tx, err := s.Conn.Begin(ctx)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Get the current slug, which is kept when the title still gives it
+	current := ""
+	err = tx.QueryRow(ctx, "SELECT slug FROM blog_series WHERE id = @id FOR UPDATE;", pgx.NamedArgs{"id": input.Id}).Scan(&current)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	slugString, err := chooseSeriesSlug(ctx, tx, input.Title, input.Slug, current)
+	if err != nil {
+		return value, databaseError(err)
+	}
+
+	// Update series
+	sql := "UPDATE blog_series SET title=@title, slug=@slug, description=@description WHERE id=@id;"
+	args := pgx.NamedArgs{
+		"id":          input.Id,
+		"title":       input.Title,
+		"slug":        slugString,
+		"description": input.Description,
+	}
+	if _, err := tx.Exec(ctx, sql, args); err != nil {
+		return value, databaseError(err)
+	}
+	if err := setSeriesParts(ctx, tx, input.Id, input.Parts); err != nil {
+		return value, databaseError(err)
+	}
+
+	value, err = s.getSeries(ctx, tx, "id = @id", pgx.NamedArgs{"id": input.Id}, models.STATUS_ALL)
+	if err != nil {
+		return value, databaseError(err)
+	}
+	return value, databaseError(tx.Commit(ctx))
+}
+
+// chooseSeriesSlug returns the slug to save a series with inside tx, like
+// chooseSlug does for posts. A custom slug taken by another series fails on
+// the unique constraint.
+func chooseSeriesSlug(ctx context.Context, tx pgx.Tx, title string, custom string, current string) (string, error) {
+	if custom != "" {
+		return custom, nil
+	}
+
+	var err error
+	value := pickSlug(baseSeriesSlug(title), current, func(slug string) bool {
+		taken := false
+		if err == nil {
+			err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM blog_series WHERE slug = @slug);", pgx.NamedArgs{"slug": slug}).Scan(&taken)
+		}
+		return taken && slug != current
+	})
+	return value, err
+}
+
+// setSeriesParts replaces the parts of a series inside tx, posts already
+// part of another series fail on the unique constraint
+func setSeriesParts(ctx context.Context, tx pgx.Tx, seriesId string, parts []models.BlogSeriesPart) error {
+	postIds := make([]string, len(parts))
+	positions := make([]int, len(parts))
+	for i, part := range parts {
+		postIds[i] = part.PostId
+		positions[i] = part.Position
+	}
+
+	_, err := tx.Exec(ctx, "DELETE FROM blog_series_post WHERE series_id = @series_id;", pgx.NamedArgs{"series_id": seriesId})
+	if err != nil {
+		return err
+	}
+
+	sql := `
+		INSERT INTO blog_series_post (series_id, post_id, position)
+		SELECT @series_id, part.post_id::uuid, part.position
+		FROM UNNEST(@post_ids::text[], @positions::int[]) AS part(post_id, position);
+	`
+	_, err = tx.Exec(ctx, sql, pgx.NamedArgs{"series_id": seriesId, "post_ids": postIds, "positions": positions})
+	return err
+}
+
+func (s *BlogSeriesService) Remove(ctx context.Context, id string) (string, error) {
+	// Execute SQL, parts go with the series
+	sql := "DELETE FROM blog_series WHERE id = @id RETURNING id;"
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+	value := ""
+	err := s.Conn.QueryRow(ctx, sql, args).Scan(&value)
+	if err != nil {
+		return value, databaseError(err)
+	}
+
+	// If success return nil
+	return value, nil
+}
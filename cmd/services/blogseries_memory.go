@@ -0,0 +1,203 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type MemoryBlogSeriesService struct {
+	Store *MemoryStore
+}
+
+func NewMemoryBlogSeriesService(store *MemoryStore) *MemoryBlogSeriesService {
+	return &MemoryBlogSeriesService{Store: store}
+}
+
+func (s *MemoryStore) findSeries(id string) int {
+	for i, series := range s.series {
+		if series.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryStore) findSeriesWithSlug(slug string) int {
+	for i, series := range s.series {
+		if series.Slug == slug {
+			return i
+		}
+	}
+	return -1
+}
+
+// seriesPosts behaves like the Postgres seriesPosts
+func (s *MemoryStore) seriesPosts(seriesId string, status string) []models.BlogSeriesPost {
+	value := []models.BlogSeriesPost{}
+	for _, part := range s.parts {
+		i := s.findPost(part.postId)
+		if part.seriesId != seriesId || i < 0 || !hasStatus(s.posts[i], status) {
+			continue
+		}
+		post := s.posts[i]
+		value = append(value, models.BlogSeriesPost{Id: post.Id, Title: post.Title, Slug: post.Slug, Position: part.position})
+	}
+	slices.SortFunc(value, func(a, b models.BlogSeriesPost) int { return cmp.Compare(a.Position, b.Position) })
+	return value
+}
+
+// postSeries behaves like the Postgres postSeries
+func (s *MemoryStore) postSeries(postId string, status string) *models.BlogPostSeries {
+	for _, part := range s.parts {
+		if part.postId != postId {
+			continue
+		}
+		if i := s.findSeries(part.seriesId); i >= 0 {
+			return seriesContext(s.series[i], s.seriesPosts(part.seriesId, status), postId)
+		}
+	}
+	return nil
+}
+
+// setSeriesParts behaves like the Postgres setSeriesParts
+func (s *MemoryStore) setSeriesParts(seriesId string, parts []models.BlogSeriesPart) error {
+	for _, part := range parts {
+		postId := strings.ToLower(part.PostId)
+		if s.findPost(postId) < 0 {
+			return fmt.Errorf("%w: post %q does not exist", ErrInvalidReference, part.PostId)
+		}
+		if slices.ContainsFunc(s.parts, func(other memorySeriesPart) bool { return other.postId == postId && other.seriesId != seriesId }) {
+			return fmt.Errorf("%w: post %q is part of another series", ErrConflict, part.PostId)
+		}
+	}
+
+	s.removeSeriesParts(func(part memorySeriesPart) bool { return part.seriesId == seriesId })
+	for _, part := range parts {
+		s.parts = append(s.parts, memorySeriesPart{seriesId: seriesId, postId: strings.ToLower(part.PostId), position: part.Position})
+	}
+	return nil
+}
+
+// chooseSeriesSlug behaves like the Postgres chooseSeriesSlug
+func (s *MemoryStore) chooseSeriesSlug(title string, custom string, current string) string {
+	if custom != "" {
+		return custom
+	}
+	return pickSlug(baseSeriesSlug(title), current, func(slug string) bool {
+		return slug != current && s.findSeriesWithSlug(slug) >= 0
+	})
+}
+
+func (s *MemoryBlogSeriesService) GetAll(ctx context.Context) ([]models.BlogSeries, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	value := slices.Clone(s.Store.series)
+	slices.SortFunc(value, func(a, b models.BlogSeries) int {
+		return cmp.Or(strings.Compare(a.Title, b.Title), strings.Compare(a.Id, b.Id))
+	})
+	if value == nil {
+		value = []models.BlogSeries{}
+	}
+	return value, nil
+}
+
+func (s *MemoryBlogSeriesService) GetWithSlug(ctx context.Context, slug string, status string) (models.BlogSeriesWithPosts, error) {
+	if err := ctx.Err(); err != nil {
+		return models.BlogSeriesWithPosts{}, err
+	}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	i := s.Store.findSeriesWithSlug(slug)
+	if i < 0 {
+		return models.BlogSeriesWithPosts{}, ErrNotFound
+	}
+	series := s.Store.series[i]
+	return models.BlogSeriesWithPosts{BlogSeries: series, Posts: s.Store.seriesPosts(series.Id, status)}, nil
+}
+
+func (s *MemoryBlogSeriesService) Create(ctx context.Context, input *models.BlogSeriesCreated) (models.BlogSeriesWithPosts, error) {
+	if err := ctx.Err(); err != nil {
+		return models.BlogSeriesWithPosts{}, err
+	}
+
+	s.Store.mu.Lock()
+	defer s.Store.mu.Unlock()
+
+	slugString := s.Store.chooseSeriesSlug(input.Title, input.Slug, "")
+	if s.Store.findSeriesWithSlug(slugString) >= 0 {
+		return models.BlogSeriesWithPosts{}, fmt.Errorf("%w: series slug %q already exists", ErrConflict, slugString)
+	}
+
+	value := models.BlogSeries{
+		Id:          newMemoryId(),
+		Title:       input.Title,
+		Slug:        slugString,
+		Description: input.Description,
+	}
+	if err := s.Store.setSeriesParts(value.Id, input.Parts); err != nil {
+		return models.BlogSeriesWithPosts{}, err
+	}
+	s.Store.series = append(s.Store.series, value)
+
+	return models.BlogSeriesWithPosts{BlogSeries: value, Posts: s.Store.seriesPosts(value.Id, models.STATUS_ALL)}, nil
+}
+
+func (s *MemoryBlogSeriesService) Update(ctx context.Context, input *models.BlogSeriesUpdated) (models.BlogSeriesWithPosts, error) {
+	if err := ctx.Err(); err != nil {
+		return models.BlogSeriesWithPosts{}, err
+	}
+
+	s.Store.mu.Lock()
+	defer s.Store.mu.Unlock()
+
+	i := s.Store.findSeries(strings.ToLower(input.Id))
+	if i < 0 {
+		return models.BlogSeriesWithPosts{}, ErrNotFound
+	}
+
+	value := s.Store.series[i]
+	slugString := s.Store.chooseSeriesSlug(input.Title, input.Slug, value.Slug)
+	if j := s.Store.findSeriesWithSlug(slugString); j >= 0 && j != i {
+		return models.BlogSeriesWithPosts{}, fmt.Errorf("%w: series slug %q already exists", ErrConflict, slugString)
+	}
+	if err := s.Store.setSeriesParts(value.Id, input.Parts); err != nil {
+		return models.BlogSeriesWithPosts{}, err
+	}
+
+	value.Title = input.Title
+	value.Slug = slugString
+	value.Description = input.Description
+	s.Store.series[i] = value
+
+	return models.BlogSeriesWithPosts{BlogSeries: value, Posts: s.Store.seriesPosts(value.Id, models.STATUS_ALL)}, nil
+}
+
+func (s *MemoryBlogSeriesService) Remove(ctx context.Context, id string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	s.Store.mu.Lock()
+	defer s.Store.mu.Unlock()
+
+	i := s.Store.findSeries(id)
+	if i < 0 {
+		return "", ErrNotFound
+	}
+
+	// Remove series and its parts like ON DELETE CASCADE
+	s.Store.series = append(s.Store.series[:i], s.Store.series[i+1:]...)
+	s.Store.removeSeriesParts(func(part memorySeriesPart) bool { return part.seriesId == id })
+	return id, nil
+}
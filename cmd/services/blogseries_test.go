@@ -0,0 +1,146 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BlogSeriesService(t *testing.T) {
+	ctx := context.Background()
+	database := openTestDatabase(t)
+	postService := NewBlogPostService(database)
+	seriesService := NewBlogSeriesService(database)
+
+	posts := []models.BlogPostContentWithTags{}
+	for _, input := range []models.BlogPostCreated{
+		{Title: "series first part"},
+		{Title: "series draft part", IsDraft: true},
+		{Title: "series last part"},
+	} {
+		post, err := postService.Create(ctx, &input)
+		assert.NoError(t, err)
+		posts = append(posts, post)
+	}
+	defer func() {
+		for _, post := range posts[:2] {
+			_, err := postService.Remove(ctx, post.Id)
+			assert.NoError(t, err)
+		}
+	}()
+	first, draft, last := posts[0], posts[1], posts[2]
+	id := ""
+
+	t.Run("Create success", func(t *testing.T) {
+		// Positions order the parts whatever the order they are given in
+		value, err := seriesService.Create(ctx, &models.BlogSeriesCreated{
+			Title:       "Go tutorial",
+			Description: "learn go",
+			Parts: []models.BlogSeriesPart{
+				{PostId: last.Id, Position: 30},
+				{PostId: first.Id, Position: 10},
+				{PostId: draft.Id, Position: 20},
+			},
+		})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, value.Id)
+		assert.Equal(t, "go-tutorial", value.Slug)
+		assert.Equal(t, []string{first.Id, draft.Id, last.Id}, seriesPostIds(value.Posts))
+
+		id = value.Id
+	})
+
+	t.Run("GetWithSlug success", func(t *testing.T) {
+		value, err := seriesService.GetWithSlug(ctx, "go-tutorial", models.STATUS_ALL)
+		assert.NoError(t, err)
+		assert.Equal(t, "learn go", value.Description)
+		assert.Equal(t, []string{first.Id, draft.Id, last.Id}, seriesPostIds(value.Posts))
+
+		// Drafts aren't listed for the published status
+		value, err = seriesService.GetWithSlug(ctx, "go-tutorial", models.STATUS_PUBLISHED)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{first.Id, last.Id}, seriesPostIds(value.Posts))
+
+		_, err = seriesService.GetWithSlug(ctx, "unknown", models.STATUS_ALL)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("GetAll success", func(t *testing.T) {
+		data, err := seriesService.GetAll(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(data))
+		assert.Equal(t, id, data[0].Id)
+	})
+
+	t.Run("Post GetWithSlug returns series context", func(t *testing.T) {
+		value, err := postService.GetWithSlug(ctx, last.Slug, models.STATUS_ALL)
+		assert.NoError(t, err)
+		assert.Equal(t, id, value.Series.Id)
+		assert.Equal(t, 3, value.Series.Part)
+		assert.Equal(t, 3, value.Series.Total)
+		assert.Equal(t, draft.Id, value.Series.Prev.Id)
+		assert.Nil(t, value.Series.Next)
+
+		// Parts are counted among the posts the caller may see
+		value, err = postService.GetWithSlug(ctx, last.Slug, models.STATUS_PUBLISHED)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, value.Series.Part)
+		assert.Equal(t, 2, value.Series.Total)
+		assert.Equal(t, first.Id, value.Series.Prev.Id)
+	})
+
+	t.Run("Create failed with post of another series", func(t *testing.T) {
+		_, err := seriesService.Create(ctx, &models.BlogSeriesCreated{
+			Title: "Other tutorial",
+			Parts: []models.BlogSeriesPart{{PostId: first.Id, Position: 1}},
+		})
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+
+	t.Run("Create failed with unknown post", func(t *testing.T) {
+		_, err := seriesService.Create(ctx, &models.BlogSeriesCreated{
+			Title: "Other tutorial",
+			Parts: []models.BlogSeriesPart{{PostId: "00000000-0000-4000-8000-000000000000", Position: 1}},
+		})
+		assert.ErrorIs(t, err, ErrInvalidReference)
+	})
+
+	t.Run("Update success", func(t *testing.T) {
+		value, err := seriesService.Update(ctx, &models.BlogSeriesUpdated{
+			Id:    id,
+			Title: "Go tutorial",
+			Parts: []models.BlogSeriesPart{
+				{PostId: last.Id, Position: 1},
+				{PostId: first.Id, Position: 2},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "go-tutorial", value.Slug)
+		assert.Equal(t, []string{last.Id, first.Id}, seriesPostIds(value.Posts))
+
+		// Posts left out aren't part of the series anymore
+		post, err := postService.GetWithSlug(ctx, draft.Slug, models.STATUS_ALL)
+		assert.NoError(t, err)
+		assert.Nil(t, post.Series)
+	})
+
+	t.Run("Remove post removes it from series", func(t *testing.T) {
+		_, err := postService.Remove(ctx, last.Id)
+		assert.NoError(t, err)
+
+		value, err := seriesService.GetWithSlug(ctx, "go-tutorial", models.STATUS_ALL)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{first.Id}, seriesPostIds(value.Posts))
+	})
+
+	t.Run("Remove success", func(t *testing.T) {
+		value, err := seriesService.Remove(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, id, value)
+
+		_, err = seriesService.Remove(ctx, id)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -1,91 +1,154 @@
 package services
 
 import (
-	"api-chi/cmd/config"
 	"api-chi/cmd/models"
+	"context"
+	"errors"
 
 	"github.com/jackc/pgx/v5"
 )
 
 type BlogTagService struct {
-	Conn DatabaseService
+	Conn *DatabaseService
 }
 
-func (s *BlogTagService) Open() error {
-	return s.Conn.Open()
+func NewBlogTagService(conn *DatabaseService) *BlogTagService {
+	return &BlogTagService{Conn: conn}
 }
 
-func (s *BlogTagService) Close() {
-	s.Conn.Close()
+func (s *BlogTagService) Count(ctx context.Context, search string) (int, error) {
+	value, err := countTags(ctx, s.Conn, search)
+	return value, databaseError(err)
 }
 
-func (s *BlogTagService) Count(search string) (int, error) {
+// countTags returns the number of tags with search in their name
+func countTags(ctx context.Context, q querier, search string) (int, error) {
 	// Execute SQL
 	sql := "SELECT COUNT(id) FROM blog_tag WHERE name ILIKE '%' || @search || '%';"
 	args := pgx.NamedArgs{
 		"search": search,
 	}
 	value := 0
-	err := s.Conn.QueryRow(config.CTX, sql, args).Scan(&value)
-	if err != nil {
-		return value, err
-	}
-
-	// If success return nil
-	return value, nil
+	err := q.QueryRow(ctx, sql, args).Scan(&value)
+	return value, err
 }
 
-func (s *BlogTagService) GetAll(search string, limit int, page int) ([]models.BlogTag, error) {
+func (s *BlogTagService) GetAll(ctx context.Context, search string, sort models.Sort, limit int, page int) ([]models.BlogTag, models.PageMeta, error) {
 	// Set default range for page
-	if page < 1 {
-		page = 0
-	} else {
-		page -= 1
-	}
+	page = pageNumber(page)
 
 	// Execute SQL
-	sql := "SELECT id, name FROM blog_tag WHERE name ILIKE '%' || @search || '%' LIMIT @limit OFFSET @page"
+	sql := "SELECT id, name FROM blog_tag WHERE name ILIKE '%' || @search || '%'"
+	sql += orderBy(tagSort(sort), tagSortColumns, "blog_tag.id") + " LIMIT @limit OFFSET @page;"
 	args := pgx.NamedArgs{
 		"search": search,
 		"limit":  limit,
-		"page":   page*limit,
+		"page":   (page - 1) * limit,
+	}
+
+	// The total and the page are read from the same snapshot
+	value := []models.BlogTag{}
+	meta := models.PageMeta{}
+	err := s.Conn.readSnapshot(ctx, func(tx pgx.Tx) error {
+		total, err := countTags(ctx, tx, search)
+		if err != nil {
+			return err
+		}
+		meta = newPageMeta(total, limit, page)
+
+		rows, err := tx.Query(ctx, sql, args)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			item := models.BlogTag{}
+
+			if err := rows.Scan(&item.Id, &item.Name); err != nil {
+				return err
+			}
+
+			value = append(value, item)
+		}
+		return rows.Err()
+	})
+
+	return value, meta, databaseError(err)
+}
+
+// GetAllWithCursor returns the page of tags after or before the one cursor
+// points at, the first page when cursor is nil, with the cursors of the
+// pages around it. The sort of the cursor wins over sort.
+func (s *BlogTagService) GetAllWithCursor(ctx context.Context, search string, sort models.Sort, cursor *models.Cursor, limit int) ([]models.BlogTag, models.Cursors, error) {
+	// One more row is read than asked for, which Postgres can't catch
+	if limit < 0 {
+		return nil, models.Cursors{}, errors.New("LIMIT must not be negative")
+	}
+
+	// Keep the rows after the cursor
+	where := "WHERE name ILIKE '%' || @search || '%'"
+	args := pgx.NamedArgs{
+		"search": search,
+		"limit":  limit + 1,
 	}
+	sort = tagSort(sort)
+	if cursor != nil {
+		sort = tagSort(cursor.Sort)
+		condition, err := keysetCondition(*cursor, sort, tagSortColumns, "blog_tag.id", args)
+		if err != nil {
+			return nil, models.Cursors{}, err
+		}
+		where += " AND " + condition
+	}
+
+	// Execute SQL, one more row tells whether there is a page after this one
+	sql := "SELECT id, name, " + cursorKeysSql(sort, tagSortColumns, "blog_tag.id") + " FROM blog_tag " + where
+	sql += orderBy(pageSort(sort, cursor), tagSortColumns, "blog_tag.id") + " LIMIT @limit;"
 	value := []models.BlogTag{}
-	rows, err := s.Conn.Query(config.CTX, sql, args)
+	keys := [][]string{}
+	rows, err := s.Conn.Query(ctx, sql, args)
 	if err != nil {
-		return value, err
+		return value, models.Cursors{}, cursorError(err)
 	}
+	defer rows.Close()
+
 	for rows.Next() {
 		item := models.BlogTag{}
+		itemKeys := []string{}
 
-		if err := rows.Scan(&item.Id, &item.Name); err != nil {
-			return nil, err
+		if err := rows.Scan(&item.Id, &item.Name, &itemKeys); err != nil {
+			return nil, models.Cursors{}, cursorError(err)
 		}
 
 		value = append(value, item)
+		keys = append(keys, itemKeys)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, models.Cursors{}, cursorError(err)
 	}
 
-	// If success return nil
-	return value, nil
+	value, cursors := cursorPage(value, keys, sort, cursor, limit)
+	return value, cursors, nil
 }
 
-func (s *BlogTagService) Create(input *models.BlogTag) (models.BlogTag, error) {
+func (s *BlogTagService) Create(ctx context.Context, input *models.BlogTag) (models.BlogTag, error) {
 	// Execute SQL
 	sql := "INSERT INTO blog_tag (name) VALUES (@name) RETURNING id, name;"
 	args := pgx.NamedArgs{
 		"name": input.Name,
 	}
 	value := models.BlogTag{}
-	err := s.Conn.QueryRow(config.CTX, sql, args).Scan(&value.Id, &value.Name)
+	err := s.Conn.QueryRow(ctx, sql, args).Scan(&value.Id, &value.Name)
 	if err != nil {
-		return value, err
+		return value, databaseError(err)
 	}
 
 	// If success return nil
 	return value, nil
 }
 
-func (s *BlogTagService) Update(input *models.BlogTag) (models.BlogTag, error) {
+func (s *BlogTagService) Update(ctx context.Context, input *models.BlogTag) (models.BlogTag, error) {
 	// Execute SQL
 	sql := "UPDATE blog_tag SET name=@name WHERE id=@id RETURNING id, name;"
 	args := pgx.NamedArgs{
@@ -93,25 +156,25 @@ func (s *BlogTagService) Update(input *models.BlogTag) (models.BlogTag, error) {
 		"name": input.Name,
 	}
 	value := models.BlogTag{}
-	err := s.Conn.QueryRow(config.CTX, sql, args).Scan(&value.Id, &value.Name)
+	err := s.Conn.QueryRow(ctx, sql, args).Scan(&value.Id, &value.Name)
 	if err != nil {
-		return value, err
+		return value, databaseError(err)
 	}
 
 	// If success return nil
 	return value, nil
 }
 
-func (s *BlogTagService) Remove(id string) (string, error) {
+func (s *BlogTagService) Remove(ctx context.Context, id string) (string, error) {
 	// Execute SQL
 	sql := "DELETE FROM blog_tag WHERE id = @id RETURNING id;"
 	args := pgx.NamedArgs{
 		"id": id,
 	}
 	value := ""
-	err := s.Conn.QueryRow(config.CTX, sql, args).Scan(&value)
+	err := s.Conn.QueryRow(ctx, sql, args).Scan(&value)
 	if err != nil {
-		return value, err
+		return value, databaseError(err)
 	}
 
 	// If success return nil
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"context"
+	"errors"
+	"fmt"
+)
+
+type MemoryBlogTagService struct {
+	Store *MemoryStore
+}
+
+func NewMemoryBlogTagService(store *MemoryStore) *MemoryBlogTagService {
+	return &MemoryBlogTagService{Store: store}
+}
+
+func (s *MemoryBlogTagService) findTagWithName(name string) int {
+	for i, tag := range s.Store.tags {
+		if tag.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryBlogTagService) Count(ctx context.Context, search string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	value := 0
+	for _, tag := range s.Store.tags {
+		if containsFold(tag.Name, search) {
+			value += 1
+		}
+	}
+	return value, nil
+}
+
+func (s *MemoryBlogTagService) GetAll(ctx context.Context, search string, sort models.Sort, limit int, page int) ([]models.BlogTag, models.PageMeta, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, models.PageMeta{}, err
+	}
+
+	if limit < 0 {
+		return nil, models.PageMeta{}, errors.New("LIMIT must not be negative")
+	}
+
+	// Set default range for page
+	page = pageNumber(page)
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	tags := []models.BlogTag{}
+	for _, tag := range s.Store.tags {
+		if containsFold(tag.Name, search) {
+			tags = append(tags, tag)
+		}
+	}
+	s.Store.sortTags(tags, tagSort(sort))
+
+	start, end := paginate(len(tags), limit, (page-1)*limit)
+	return tags[start:end], newPageMeta(len(tags), limit, page), nil
+}
+
+func (s *MemoryBlogTagService) GetAllWithCursor(ctx context.Context, search string, sort models.Sort, cursor *models.Cursor, limit int) ([]models.BlogTag, models.Cursors, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, models.Cursors{}, err
+	}
+
+	if limit < 0 {
+		return nil, models.Cursors{}, errors.New("LIMIT must not be negative")
+	}
+
+	sort = tagSort(sort)
+	if cursor != nil {
+		sort = tagSort(cursor.Sort)
+	}
+
+	s.Store.mu.RLock()
+	defer s.Store.mu.RUnlock()
+
+	tags := []models.BlogTag{}
+	for _, tag := range s.Store.tags {
+		if containsFold(tag.Name, search) {
+			tags = append(tags, tag)
+		}
+	}
+	s.Store.sortTags(tags, sort)
+
+	// Keep the tags after the cursor
+	usage := s.Store.tagUsage()
+	if cursor != nil {
+		probe, probeUsage, err := tagFromKeys(cursor.Keys, sort)
+		if err != nil {
+			return nil, models.Cursors{}, err
+		}
+		tags = afterCursor(tags, cursor, limit, func(tag models.BlogTag) int {
+			return compareTags(tag, usage[tag.Id], probe, probeUsage, sort)
+		})
+	} else {
+		tags = afterCursor(tags, nil, limit, nil)
+	}
+
+	keys := [][]string{}
+	for _, tag := range tags {
+		keys = append(keys, tagKeys(tag, usage[tag.Id], sort))
+	}
+	value, cursors := cursorPage(tags, keys, sort, cursor, limit)
+	return value, cursors, nil
+}
+
+func (s *MemoryBlogTagService) Create(ctx context.Context, input *models.BlogTag) (models.BlogTag, error) {
+	if err := ctx.Err(); err != nil {
+		return models.BlogTag{}, err
+	}
+
+	s.Store.mu.Lock()
+	defer s.Store.mu.Unlock()
+
+	if s.findTagWithName(input.Name) >= 0 {
+		return models.BlogTag{}, fmt.Errorf("%w: tag name %q already exists", ErrConflict, input.Name)
+	}
+
+	value := models.BlogTag{Id: newMemoryId(), Name: input.Name}
+	s.Store.tags = append(s.Store.tags, value)
+	return value, nil
+}
+
+func (s *MemoryBlogTagService) Update(ctx context.Context, input *models.BlogTag) (models.BlogTag, error) {
+	if err := ctx.Err(); err != nil {
+		return models.BlogTag{}, err
+	}
+
+	s.Store.mu.Lock()
+	defer s.Store.mu.Unlock()
+
+	i := s.Store.findTag(input.Id)
+	if i < 0 {
+		return models.BlogTag{}, ErrNotFound
+	}
+	if j := s.findTagWithName(input.Name); j >= 0 && j != i {
+		return models.BlogTag{}, fmt.Errorf("%w: tag name %q already exists", ErrConflict, input.Name)
+	}
+
+	s.Store.tags[i].Name = input.Name
+	return s.Store.tags[i], nil
+}
+
+func (s *MemoryBlogTagService) Remove(ctx context.Context, id string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	s.Store.mu.Lock()
+	defer s.Store.mu.Unlock()
+
+	i := s.Store.findTag(id)
+	if i < 0 {
+		return "", ErrNotFound
+	}
+
+	// Remove tag and its post links like ON DELETE CASCADE
+	s.Store.tags = append(s.Store.tags[:i], s.Store.tags[i+1:]...)
+	s.Store.removePostTags(func(link memoryPostTag) bool { return link.tagId == id })
+
+	return id, nil
+}
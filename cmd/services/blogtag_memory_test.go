@@ -0,0 +1,173 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"context"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryBlogTagService(t *testing.T) {
+	ctx := context.Background()
+	id := ""
+	service := MemoryBlogTagService{Store: NewMemoryStore()}
+
+	t.Run("Create success", func(t *testing.T) {
+		// Declare input
+		input := models.BlogTag{
+			Name: "test tag",
+		}
+
+		// Create data
+		value, err := service.Create(ctx, &input)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, value.Id)
+		assert.Equal(t, input.Name, value.Name)
+
+		// Assign value to id
+		id = value.Id
+	})
+
+	t.Run("Create failed with duplicate name", func(t *testing.T) {
+		input := models.BlogTag{
+			Name: "test tag",
+		}
+
+		_, err := service.Create(ctx, &input)
+		assert.ErrorIs(t, err, ErrConflict)
+	})
+
+	t.Run("Count success", func(t *testing.T) {
+		count, err := service.Count(ctx, "")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		count, err = service.Count(ctx, "TEST")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		count, err = service.Count(ctx, "website")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("GetAll success", func(t *testing.T) {
+		// Get all data
+		data, meta, err := service.GetAll(ctx, "", models.Sort{}, 3, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(data))
+		assert.Equal(t, id, data[0].Id)
+		assert.Equal(t, models.PageMeta{Total: 1, Page: 1, Limit: 3, TotalPages: 1}, meta)
+
+		// Second page is empty
+		data, _, err = service.GetAll(ctx, "", models.Sort{}, 3, 2)
+		assert.NoError(t, err)
+		assert.Empty(t, data)
+	})
+
+	t.Run("GetAll success with sort", func(t *testing.T) {
+		alpha, err := service.Create(ctx, &models.BlogTag{Name: "alpha"})
+		assert.NoError(t, err)
+		beta, err := service.Create(ctx, &models.BlogTag{Name: "beta"})
+		assert.NoError(t, err)
+		postService := MemoryBlogPostService{Store: service.Store}
+		post, err := postService.Create(ctx, &models.BlogPostCreated{Title: "sorted tags", Tags: []models.BlogTag{beta}})
+		assert.NoError(t, err)
+
+		tests := []struct {
+			sort  models.Sort
+			names []string
+		}{
+			{models.Sort{}, []string{"alpha", "beta", "test tag"}},
+			{models.Sort{Field: models.SORT_NAME, Order: models.ORDER_DESC}, []string{"test tag", "beta", "alpha"}},
+			{models.Sort{Field: models.SORT_USAGE}, []string{"beta"}},
+		}
+		for _, test := range tests {
+			data, _, err := service.GetAll(ctx, "", test.sort, 10, 1)
+			assert.NoError(t, err)
+			names := []string{}
+			for _, tag := range data {
+				names = append(names, tag.Name)
+			}
+			assert.Equal(t, test.names, names[:len(test.names)], test.sort.Field)
+		}
+
+		_, err = postService.Remove(ctx, post.Id)
+		assert.NoError(t, err)
+		_, err = service.Remove(ctx, alpha.Id)
+		assert.NoError(t, err)
+		_, err = service.Remove(ctx, beta.Id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("GetAllWithCursor success", func(t *testing.T) {
+		alpha, err := service.Create(ctx, &models.BlogTag{Name: "alpha"})
+		assert.NoError(t, err)
+		beta, err := service.Create(ctx, &models.BlogTag{Name: "beta"})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = service.Remove(ctx, alpha.Id)
+			assert.NoError(t, err)
+			_, err = service.Remove(ctx, beta.Id)
+			assert.NoError(t, err)
+		}()
+
+		// Walk forward one tag at a time
+		names := []string{}
+		var cursor *models.Cursor
+		cursors := models.Cursors{}
+		for range 3 {
+			data := []models.BlogTag{}
+			data, cursors, err = service.GetAllWithCursor(ctx, "", models.Sort{}, cursor, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, len(data))
+			names = append(names, data[0].Name)
+			if cursors.Next == "" {
+				break
+			}
+			next, err := models.DecodeCursor(cursors.Next)
+			assert.NoError(t, err)
+			cursor = &next
+		}
+		assert.Equal(t, []string{"alpha", "beta", "test tag"}, names)
+		assert.Empty(t, cursors.Next)
+
+		// And back from the last one
+		prev, err := models.DecodeCursor(cursors.Prev)
+		assert.NoError(t, err)
+		data, cursors, err := service.GetAllWithCursor(ctx, "", models.Sort{}, &prev, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.BlogTag{beta}, data)
+		assert.NotEmpty(t, cursors.Next)
+		assert.NotEmpty(t, cursors.Prev)
+
+		_, _, err = service.GetAllWithCursor(ctx, "", models.Sort{}, &models.Cursor{Sort: models.Sort{Field: models.SORT_USAGE}, Keys: []string{"many", beta.Id}}, 1)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("Update success", func(t *testing.T) {
+		// Declare input
+		input := models.BlogTag{
+			Id:   id,
+			Name: "this is test tag",
+		}
+
+		// Update data
+		value, err := service.Update(ctx, &input)
+		assert.NoError(t, err)
+		assert.Equal(t, input.Id, value.Id)
+		assert.Equal(t, input.Name, value.Name)
+	})
+
+	t.Run("Remove success", func(t *testing.T) {
+		value, err := service.Remove(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, id, value)
+
+		// Removing twice fails
+		_, err = service.Remove(ctx, id)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"api-chi/cmd/models"
+	"context"
 
 	"testing"
 
@@ -9,22 +10,18 @@ import (
 )
 
 func Test_BlogTagService(t *testing.T) {
+	ctx := context.Background()
 	id := ""
-	service := BlogTagService{}
+	service := NewBlogTagService(openTestDatabase(t))
 
 	t.Run("Create success", func(t *testing.T) {
-		// Connect database
-		err := service.Open()
-		defer service.Close()
-		assert.NoError(t, err)
-
 		// Declare input
 		input := models.BlogTag{
 			Name: "test tag",
 		}
 
 		// Create database
-		value, err := service.Create(&input)
+		value, err := service.Create(ctx, &input)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, value)
 		assert.NotEmpty(t, value.Id)
@@ -35,34 +32,25 @@ func Test_BlogTagService(t *testing.T) {
 	})
 
 	t.Run("Count success", func(t *testing.T) {
-		// Connect database
-		err := service.Open()
-		assert.NoError(t, err)
-		defer service.Close()
-
 		// Declare input
 		search := ""
 
 		// Count database
-		count, err := service.Count(search)
+		count, err := service.Count(ctx, search)
 		assert.NoError(t, err)
 		assert.Equal(t, count, 1)
 	})
 
 	t.Run("GetAll success", func(t *testing.T) {
-		// Connect database
-		err := service.Open()
-		defer service.Close()
-		assert.NoError(t, err)
-
 		// Declare input
 		search := ""
 		limit := 3
 		page := 1
 
 		// Get all database
-		data, err := service.GetAll(search, limit, page)
+		data, meta, err := service.GetAll(ctx, search, models.Sort{}, limit, page)
 		assert.NoError(t, err)
+		assert.Equal(t, models.PageMeta{Total: 1, Page: 1, Limit: 3, TotalPages: 1}, meta)
 		assert.IsType(t, data[0], models.BlogTag{})
 		count := 0
 		for _, item := range data {
@@ -73,12 +61,61 @@ func Test_BlogTagService(t *testing.T) {
 		assert.Equal(t, 1, count)
 	})
 
-	t.Run("Update success", func(t *testing.T) {
-		// Connect database
-		err := service.Open()
-		defer service.Close()
+	t.Run("GetAll success with sort", func(t *testing.T) {
+		other, err := service.Create(ctx, &models.BlogTag{Name: "another tag"})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = service.Remove(ctx, other.Id)
+			assert.NoError(t, err)
+		}()
+
+		data, _, err := service.GetAll(ctx, "tag", models.Sort{Field: models.SORT_NAME}, 10, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, other.Id, data[0].Id)
+
+		data, _, err = service.GetAll(ctx, "tag", models.Sort{Field: models.SORT_NAME, Order: models.ORDER_DESC}, 10, 1)
 		assert.NoError(t, err)
+		assert.Equal(t, other.Id, data[len(data)-1].Id)
 
+		_, _, err = service.GetAll(ctx, "tag", models.Sort{Field: models.SORT_USAGE}, 10, 1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("GetAllWithCursor success", func(t *testing.T) {
+		other, err := service.Create(ctx, &models.BlogTag{Name: "another tag"})
+		assert.NoError(t, err)
+		defer func() {
+			_, err = service.Remove(ctx, other.Id)
+			assert.NoError(t, err)
+		}()
+
+		for _, sort := range []models.Sort{{}, {Field: models.SORT_USAGE}} {
+			all, _, err := service.GetAll(ctx, "tag", sort, 10, 1)
+			assert.NoError(t, err)
+
+			first, cursors, err := service.GetAllWithCursor(ctx, "tag", sort, nil, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, all[:1], first, sort.Field)
+			assert.Empty(t, cursors.Prev)
+
+			next, err := models.DecodeCursor(cursors.Next)
+			assert.NoError(t, err)
+			data, cursors, err := service.GetAllWithCursor(ctx, "tag", sort, &next, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, all[1:2], data, sort.Field)
+
+			prev, err := models.DecodeCursor(cursors.Prev)
+			assert.NoError(t, err)
+			data, _, err = service.GetAllWithCursor(ctx, "tag", sort, &prev, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, first, data, sort.Field)
+		}
+
+		_, _, err = service.GetAllWithCursor(ctx, "tag", models.Sort{}, &models.Cursor{Keys: []string{"tag", "not an id"}}, 1)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("Update success", func(t *testing.T) {
 		// Declare input
 		input := models.BlogTag{
 			Id:   id,
@@ -86,7 +123,7 @@ func Test_BlogTagService(t *testing.T) {
 		}
 
 		// Update database
-		value, err := service.Update(&input)
+		value, err := service.Update(ctx, &input)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, value)
 		assert.Equal(t, input.Id, value.Id)
@@ -94,13 +131,8 @@ func Test_BlogTagService(t *testing.T) {
 	})
 
 	t.Run("Remove success", func(t *testing.T) {
-		// Connect database
-		err := service.Open()
-		defer service.Close()
-		assert.NoError(t, err)
-
 		// Remove database
-		value, err := service.Remove(id)
+		value, err := service.Remove(ctx, id)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, value)
 	})
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// cursorKeysSql returns the SQL array of the values a row is sorted by as
+// text, cursors keep them to find the row again
+func cursorKeysSql(sort models.Sort, columns map[string]sortColumn, id string) string {
+	keys := []string{}
+	for _, expr := range append(slices.Clone(columns[sort.Field].exprs), id) {
+		keys = append(keys, expr+"::text")
+	}
+	return "ARRAY[" + strings.Join(keys, ", ") + "]"
+}
+
+// keysetCondition adds the keys of cursor to args and returns the SQL
+// condition keeping the rows after the one it points at in its sort, or
+// before it for a cursor to the previous page
+func keysetCondition(cursor models.Cursor, sort models.Sort, columns map[string]sortColumn, id string, args pgx.NamedArgs) (string, error) {
+	column := columns[sort.Field]
+	exprs := append(slices.Clone(column.exprs), id)
+	types := append(slices.Clone(column.types), "uuid")
+	if len(cursor.Keys) != len(exprs) {
+		return "", fmt.Errorf("%w: %d keys for sort %q", ErrInvalidCursor, len(cursor.Keys), sort.Field)
+	}
+
+	keys := []string{}
+	for i, keyType := range types {
+		name := fmt.Sprintf("key_%d", i)
+		args[name] = cursor.Keys[i]
+		keys = append(keys, "@"+name+"::"+keyType)
+	}
+
+	// Rows are compared as a whole so that ties go on with the next column
+	operator := ">"
+	if (sort.Order == models.ORDER_DESC) != cursor.Before {
+		operator = "<"
+	}
+	return "(" + strings.Join(exprs, ", ") + ") " + operator + " (" + strings.Join(keys, ", ") + ")", nil
+}
+
+// pageSort returns the sort rows are read in, pages before a cursor are
+// read backward from it
+func pageSort(sort models.Sort, cursor *models.Cursor) models.Sort {
+	if cursor == nil || !cursor.Before {
+		return sort
+	}
+	if sort.Order == models.ORDER_DESC {
+		sort.Order = models.ORDER_ASC
+	} else {
+		sort.Order = models.ORDER_DESC
+	}
+	return sort
+}
+
+// cursorPage turns the rows read after or before cursor, with one more row
+// than limit when there is one, into the page in the order of the sort and
+// the cursors of the pages around it. keys holds the keys of every row.
+func cursorPage[T any](items []T, keys [][]string, sort models.Sort, cursor *models.Cursor, limit int) ([]T, models.Cursors) {
+	before := cursor != nil && cursor.Before
+	more := len(items) > limit
+	if more {
+		items, keys = items[:limit], keys[:limit]
+	}
+	if before {
+		slices.Reverse(items)
+		slices.Reverse(keys)
+	}
+
+	value := models.Cursors{}
+	if len(items) == 0 {
+		return items, value
+	}
+
+	// Reading backward there is always the page the cursor came from
+	if more || before {
+		value.Next = models.Cursor{Sort: sort, Keys: keys[len(keys)-1]}.Encode()
+	}
+	if (more && before) || (cursor != nil && !before) {
+		value.Prev = models.Cursor{Sort: sort, Before: true, Keys: keys[0]}.Encode()
+	}
+	return items, value
+}
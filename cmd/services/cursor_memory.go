@@ -0,0 +1,35 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"slices"
+)
+
+// afterCursor behaves like keysetCondition for sorted rows, compare tells
+// where a row is from the one cursor points at in the sort. Rows are
+// returned in the order they are read, with one more row than limit when
+// there is one.
+func afterCursor[T any](rows []T, cursor *models.Cursor, limit int, compare func(T) int) []T {
+	value := []T{}
+	if cursor == nil {
+		value = rows
+	} else if cursor.Before {
+		for _, row := range rows {
+			if compare(row) < 0 {
+				value = append(value, row)
+			}
+		}
+		slices.Reverse(value)
+	} else {
+		for _, row := range rows {
+			if compare(row) > 0 {
+				value = append(value, row)
+			}
+		}
+	}
+
+	if len(value) > limit+1 {
+		value = value[:limit+1]
+	}
+	return value
+}
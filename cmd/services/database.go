@@ -2,26 +2,98 @@ package services
 
 import (
 	"api-chi/cmd/config"
+	"context"
 	"errors"
+	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// querier runs queries on the pool or in a transaction
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type DatabaseService struct {
 	*pgxpool.Pool
+
+	// SearchLanguage is the text search configuration posts are indexed with
+	SearchLanguage string
+}
+
+// NewDatabaseService opens the connection pool shared by every service,
+// it should be called once when the application starts
+func NewDatabaseService(ctx context.Context, cfg config.PostgresConfig) (*DatabaseService, error) {
+	s := &DatabaseService{}
+	if err := s.Open(ctx, cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
-func (s *DatabaseService) Open() error {
-	config.LoadDatabaseConfig()
-	databaseUrl := config.POSTGRES_URL
-	if databaseUrl == "" {
+func (s *DatabaseService) Open(ctx context.Context, cfg config.PostgresConfig) error {
+	if cfg.Url == "" {
 		return errors.New("database url is empty")
 	}
-	pool, err := pgxpool.New(config.CTX, databaseUrl)
+	poolConfig, err := pgxpool.ParseConfig(cfg.Url)
+	if err != nil {
+		return err
+	}
+
+	// Apply pool settings from config, zero keeps the pgxpool default
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = int32(cfg.MaxConns)
+	}
+	if cfg.MinConns > 0 {
+		poolConfig.MinConns = int32(cfg.MinConns)
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return err
 	}
 
 	s.Pool = pool
+	s.SearchLanguage = cfg.SearchLanguage
+	if s.SearchLanguage == "" {
+		s.SearchLanguage = config.SEARCH_LANGUAGE
+	}
 	return nil
 }
+
+// CheckSearchLanguage returns an error when SearchLanguage isn't a text
+// search configuration of the database, queries on posts would all fail
+func (s *DatabaseService) CheckSearchLanguage(ctx context.Context) error {
+	exists := false
+	err := s.QueryRow(ctx, "SELECT to_regconfig(@language) IS NOT NULL;", pgx.NamedArgs{"language": s.SearchLanguage}).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("postgres.search_language %q is not a text search configuration", s.SearchLanguage)
+	}
+	return nil
+}
+
+// readSnapshot runs read in a read only transaction where every query sees
+// the same snapshot, so that a total and the page it is the total of agree
+func (s *DatabaseService) readSnapshot(ctx context.Context, read func(tx pgx.Tx) error) error {
+	tx, err := s.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := read(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -1,16 +1,52 @@
 package services
 
 import (
+	"api-chi/cmd/config"
+	"context"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// skipWithoutDatabase skips tests of the Postgres services when no database is configured
+func skipWithoutDatabase(t testing.TB) {
+	if os.Getenv("POSTGRES_URL") == "" {
+		t.Skip("POSTGRES_URL is not set")
+	}
+}
+
+// testDatabaseConfig points the Postgres service tests to POSTGRES_URL
+func testDatabaseConfig() config.PostgresConfig {
+	return config.PostgresConfig{Url: os.Getenv("POSTGRES_URL")}
+}
+
+// openTestDatabase opens the connection pool shared by the Postgres service tests
+func openTestDatabase(t testing.TB) *DatabaseService {
+	skipWithoutDatabase(t)
+	database, err := NewDatabaseService(context.Background(), testDatabaseConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(database.Close)
+	return database
+}
+
 func Test_DatabaseService(t *testing.T) {
+	skipWithoutDatabase(t)
 	service := DatabaseService{}
 
 	t.Run("Connection success", func(t *testing.T) {
-		err := service.Open()
+		err := service.Open(context.Background(), testDatabaseConfig())
 		assert.NoError(t, err)
+		service.Close()
+	})
+
+	t.Run("CheckSearchLanguage", func(t *testing.T) {
+		database := openTestDatabase(t)
+		assert.NoError(t, database.CheckSearchLanguage(context.Background()))
+
+		database.SearchLanguage = "englsh"
+		assert.Error(t, database.CheckSearchLanguage(context.Background()))
 	})
 }
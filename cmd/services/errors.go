@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Errors every repository returns, whatever the storage, so that callers
+// can tell what went wrong with errors.Is
+var (
+	// ErrNotFound is returned when the row asked for doesn't exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is returned when a unique value is already taken
+	ErrConflict = errors.New("conflict")
+
+	// ErrInvalidReference is returned when a row refers to one that doesn't exist
+	ErrInvalidReference = errors.New("invalid reference")
+
+	// ErrMoved is returned when a row is asked for with a key it used to have
+	ErrMoved = errors.New("moved")
+
+	// ErrInvalidCursor is returned when a cursor doesn't fit the listing
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// SQLSTATE codes translated by databaseError
+const (
+	foreignKeyViolation       = "23503"
+	uniqueViolation           = "23505"
+	invalidTextRepresentation = "22P02"
+
+	// dataExceptionClass holds the codes of values that can't be cast
+	dataExceptionClass = "22"
+)
+
+// InvalidTagError is returned when a post is saved with a tag id that
+// doesn't belong to any tag
+type InvalidTagError struct {
+	TagId string
+}
+
+func (e *InvalidTagError) Error() string {
+	return fmt.Sprintf("tag %q does not exist", e.TagId)
+}
+
+func (e *InvalidTagError) Unwrap() error {
+	return ErrInvalidReference
+}
+
+// MovedError is returned when a post is asked for with one of its old
+// slugs, Slug is the current one
+type MovedError struct {
+	Slug string
+}
+
+func (e *MovedError) Error() string {
+	return fmt.Sprintf("post moved to slug %q", e.Slug)
+}
+
+func (e *MovedError) Unwrap() error {
+	return ErrMoved
+}
+
+// databaseError wraps errors from Postgres with the matching domain error,
+// the original error stays in the chain
+func databaseError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	pgErr := &pgconn.PgError{}
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case uniqueViolation:
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case foreignKeyViolation:
+		return fmt.Errorf("%w: %w", ErrInvalidReference, err)
+	case invalidTextRepresentation:
+		// A malformed id can't match any row
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
+
+// cursorError is databaseError for queries reading after a cursor, keys
+// Postgres can't cast mean that the cursor was tampered with
+func cursorError(err error) error {
+	pgErr := &pgconn.PgError{}
+	if errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, dataExceptionClass) {
+		return fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return databaseError(err)
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_databaseError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "No rows", err: pgx.ErrNoRows, want: ErrNotFound},
+		{name: "Unique violation", err: &pgconn.PgError{Code: "23505"}, want: ErrConflict},
+		{name: "Foreign key violation", err: &pgconn.PgError{Code: "23503"}, want: ErrInvalidReference},
+		{name: "Malformed uuid", err: &pgconn.PgError{Code: "22P02"}, want: ErrNotFound},
+		{name: "Invalid tag", err: &InvalidTagError{TagId: "x"}, want: ErrInvalidReference},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := databaseError(tt.err)
+			assert.ErrorIs(t, err, tt.want)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	t.Run("Other errors unchanged", func(t *testing.T) {
+		err := errors.New("connection refused")
+		assert.Equal(t, err, databaseError(err))
+		assert.Nil(t, databaseError(nil))
+	})
+}
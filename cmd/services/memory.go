@@ -0,0 +1,206 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore holds blog posts, tags, series and the links between them in
+// process memory. It is shared by the memory services the same way the
+// Postgres services share one database.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	tags      []models.BlogTag
+	posts     []models.BlogPostContentWithTags
+	postTags  []memoryPostTag
+	revisions []models.BlogPostRevisionContent
+	slugs     []memorySlug
+	series    []models.BlogSeries
+	parts     []memorySeriesPart
+}
+
+type memoryPostTag struct {
+	tagId  string
+	postId string
+}
+
+// memorySeriesPart puts a post at a position of a series
+type memorySeriesPart struct {
+	seriesId string
+	postId   string
+	position int
+}
+
+// memorySlug is an old slug of a post
+type memorySlug struct {
+	slug   string
+	postId string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// newMemoryId returns a random version 4 UUID like GEN_RANDOM_UUID() does
+func newMemoryId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// containsFold behaves like column ILIKE '%' || search || '%'
+func containsFold(value string, search string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(search))
+}
+
+// paginate returns the part of a list of n items selected by LIMIT and OFFSET
+func paginate(n int, limit int, offset int) (int, int) {
+	start := min(offset, n)
+	end := min(start+limit, n)
+	return start, end
+}
+
+func (s *MemoryStore) findTag(id string) int {
+	for i, tag := range s.tags {
+		if tag.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryStore) findPost(id string) int {
+	for i, post := range s.posts {
+		if post.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryStore) findPostWithSlug(slug string) int {
+	for i, post := range s.posts {
+		if post.Slug == slug {
+			return i
+		}
+	}
+	return -1
+}
+
+// movedError behaves like the Postgres movedError
+func (s *MemoryStore) movedError(slug string, status string) error {
+	// Old slugs point at the current one of the same post
+	for _, old := range s.slugs {
+		if i := s.findPost(old.postId); old.slug == slug && i >= 0 && hasStatus(s.posts[i], status) {
+			return &MovedError{Slug: s.posts[i].Slug}
+		}
+	}
+	return ErrNotFound
+}
+
+// postTagsOf returns the tags linked to a post, nil if there is none
+func (s *MemoryStore) postTagsOf(postId string) []models.BlogTag {
+	var value []models.BlogTag
+	for _, link := range s.postTags {
+		if link.postId != postId {
+			continue
+		}
+		if i := s.findTag(link.tagId); i >= 0 {
+			value = append(value, s.tags[i])
+		}
+	}
+	return value
+}
+
+// setPostTags replaces the tags linked to a post
+func (s *MemoryStore) setPostTags(postId string, tags []models.BlogTag) error {
+	for _, tag := range tags {
+		if s.findTag(strings.ToLower(tag.Id)) < 0 {
+			return &InvalidTagError{TagId: tag.Id}
+		}
+	}
+
+	s.removePostTags(func(link memoryPostTag) bool { return link.postId == postId })
+	for _, tag := range tags {
+		// A tag is linked once like the primary key of blog_post_tag
+		link := memoryPostTag{tagId: strings.ToLower(tag.Id), postId: postId}
+		if !slices.Contains(s.postTags, link) {
+			s.postTags = append(s.postTags, link)
+		}
+	}
+	return nil
+}
+
+// chooseSlug behaves like the Postgres chooseSlug
+func (s *MemoryStore) chooseSlug(title string, custom string, current string) string {
+	if custom != "" {
+		return custom
+	}
+	return pickSlug(baseSlug(title), current, func(slug string) bool {
+		return slug != current && s.findPostWithSlug(slug) >= 0
+	})
+}
+
+// setSlugHistory behaves like the Postgres setSlugHistory
+func (s *MemoryStore) setSlugHistory(postId string, oldSlug string, newSlug string) {
+	s.removeSlugs(func(old memorySlug) bool { return old.slug == newSlug || old.slug == oldSlug })
+	if oldSlug != "" && oldSlug != newSlug {
+		s.slugs = append(s.slugs, memorySlug{slug: oldSlug, postId: postId})
+	}
+}
+
+func (s *MemoryStore) removeSlugs(match func(old memorySlug) bool) {
+	kept := s.slugs[:0]
+	for _, old := range s.slugs {
+		if !match(old) {
+			kept = append(kept, old)
+		}
+	}
+	s.slugs = kept
+}
+
+// createRevision saves the current title and content of the post at index i
+func (s *MemoryStore) createRevision(i int) {
+	post := s.posts[i]
+	number := 1
+	for _, revision := range s.revisions {
+		if revision.PostId == post.Id && revision.Number >= number {
+			number = revision.Number + 1
+		}
+	}
+	s.revisions = append(s.revisions, models.BlogPostRevisionContent{
+		Id:        newMemoryId(),
+		PostId:    post.Id,
+		Number:    number,
+		Title:     post.Title,
+		Content:   post.Content,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *MemoryStore) removePostTags(match func(link memoryPostTag) bool) {
+	kept := s.postTags[:0]
+	for _, link := range s.postTags {
+		if !match(link) {
+			kept = append(kept, link)
+		}
+	}
+	s.postTags = kept
+}
+
+func (s *MemoryStore) removeSeriesParts(match func(part memorySeriesPart) bool) {
+	kept := s.parts[:0]
+	for _, part := range s.parts {
+		if !match(part) {
+			kept = append(kept, part)
+		}
+	}
+	s.parts = kept
+}
@@ -0,0 +1,24 @@
+package services
+
+import "api-chi/cmd/models"
+
+// pageNumber returns page in the range of the listings, pages start at 1
+func pageNumber(page int) int {
+	return max(page, 1)
+}
+
+// newPageMeta returns the meta of the page of a listing of total rows,
+// limit and page are the ones the page was read with
+func newPageMeta(total int, limit int, page int) models.PageMeta {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = (total + limit - 1) / limit
+	}
+	return models.PageMeta{
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+	}
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewPageMeta(t *testing.T) {
+	tests := []struct {
+		total int
+		limit int
+		page  int
+		meta  models.PageMeta
+	}{
+		{0, 10, 1, models.PageMeta{Total: 0, Page: 1, Limit: 10, TotalPages: 0}},
+		{10, 10, 1, models.PageMeta{Total: 10, Page: 1, Limit: 10, TotalPages: 1}},
+		{11, 10, 1, models.PageMeta{Total: 11, Page: 1, Limit: 10, TotalPages: 2, HasNext: true}},
+		{11, 10, 3, models.PageMeta{Total: 11, Page: 3, Limit: 10, TotalPages: 2}},
+		{5, 0, 1, models.PageMeta{Total: 5, Page: 1, Limit: 0, TotalPages: 0}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.meta, newPageMeta(test.total, test.limit, test.page))
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Publisher publishes scheduled posts once their publish_at has passed
+type Publisher struct {
+	Posts    PostRepository
+	Interval time.Duration
+}
+
+func NewPublisher(posts PostRepository, interval time.Duration) *Publisher {
+	return &Publisher{Posts: posts, Interval: interval}
+}
+
+// Run publishes due posts right away and then every Interval until ctx is
+// done. Failed runs are logged and retried on the next tick.
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.Publish(ctx); err != nil && ctx.Err() == nil {
+			log.Println("publish scheduled posts:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish runs once and returns the ids of the posts it published
+func (p *Publisher) Publish(ctx context.Context) ([]string, error) {
+	ids, err := p.Posts.PublishDue(ctx, time.Now())
+	if err != nil {
+		return ids, err
+	}
+	for _, id := range ids {
+		log.Println("Published scheduled post", id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Publisher(t *testing.T) {
+	ctx := context.Background()
+	postService := NewMemoryBlogPostService(NewMemoryStore())
+	publisher := NewPublisher(postService, time.Minute)
+
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	due, err := postService.Create(ctx, &models.BlogPostCreated{Title: "due post", IsDraft: true, PublishAt: &past})
+	assert.NoError(t, err)
+	later, err := postService.Create(ctx, &models.BlogPostCreated{Title: "later post", IsDraft: true, PublishAt: &future})
+	assert.NoError(t, err)
+	draft, err := postService.Create(ctx, &models.BlogPostCreated{Title: "draft post", IsDraft: true})
+	assert.NoError(t, err)
+
+	t.Run("Publish success", func(t *testing.T) {
+		ids, err := publisher.Publish(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{due.Id}, ids)
+
+		// Only the due post left the schedule
+		data, err := postService.GetWithId(ctx, due.Id)
+		assert.NoError(t, err)
+		assert.False(t, data.IsDraft)
+		data, err = postService.GetWithId(ctx, later.Id)
+		assert.NoError(t, err)
+		assert.True(t, data.IsDraft)
+		data, err = postService.GetWithId(ctx, draft.Id)
+		assert.NoError(t, err)
+		assert.True(t, data.IsDraft)
+
+		// Published posts aren't published twice
+		ids, err = publisher.Publish(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("Run stops with context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			publisher.Run(ctx)
+			close(done)
+		}()
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Run did not stop after cancel")
+		}
+	})
+}
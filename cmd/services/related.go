@@ -0,0 +1,34 @@
+package services
+
+import "math"
+
+// Range of the related post listings
+const (
+	RELATED_DEFAULT_LIMIT = 5
+	RELATED_MAX_LIMIT     = 20
+)
+
+// relatedScoreDigits rounds related post scores, sums of the same weights
+// added in another order may differ in the last bits and would break ties
+// on recency
+const relatedScoreDigits = 9
+
+// relatedLimit returns limit in the range of the related post listings
+func relatedLimit(limit int) int {
+	if limit < 1 {
+		return RELATED_DEFAULT_LIMIT
+	}
+	return min(limit, RELATED_MAX_LIMIT)
+}
+
+// relatedWeight returns how much sharing a tag found on usage published
+// posts counts, rare tags say more about a post than common ones
+func relatedWeight(usage int) float64 {
+	return 1 / math.Log(1+float64(usage))
+}
+
+// relatedScore rounds a sum of relatedWeight like Postgres does
+func relatedScore(score float64) float64 {
+	scale := math.Pow10(relatedScoreDigits)
+	return math.Round(score*scale) / scale
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"context"
+	"time"
+)
+
+// PostRepository is the storage used by the blog post controller.
+// BlogPostService implements it on top of Postgres and MemoryBlogPostService
+// keeps everything in process memory.
+type PostRepository interface {
+	Count(ctx context.Context, filter models.BlogPostFilter) (int, error)
+	GetWithSlug(ctx context.Context, slug string, status string) (models.BlogPostContentWithTags, error)
+	GetWithId(ctx context.Context, id string) (models.BlogPostContentWithTags, error)
+	GetAll(ctx context.Context, filter models.BlogPostFilter, limit int, page int) ([]models.BlogPostWithTags, models.PageMeta, error)
+	GetAllWithCursor(ctx context.Context, filter models.BlogPostFilter, cursor *models.Cursor, limit int) ([]models.BlogPostWithTags, models.Cursors, error)
+	GetAllWithContent(ctx context.Context, filter models.BlogPostFilter, limit int, page int) ([]models.BlogPostContentWithTags, models.PageMeta, error)
+	GetArchive(ctx context.Context, filter models.BlogPostFilter, withPosts bool) ([]models.BlogPostArchiveYear, error)
+	GetRelated(ctx context.Context, slug string, status string, limit int) ([]models.BlogPostWithTags, error)
+	Create(ctx context.Context, input *models.BlogPostCreated) (models.BlogPostContentWithTags, error)
+	Update(ctx context.Context, input *models.BlogPostUpdated) (models.BlogPostContentWithTags, error)
+	Remove(ctx context.Context, id string) (string, error)
+	PublishDue(ctx context.Context, now time.Time) ([]string, error)
+}
+
+// TagRepository is the storage used by the blog tag controller.
+// BlogTagService implements it on top of Postgres and MemoryBlogTagService
+// keeps everything in process memory.
+type TagRepository interface {
+	Count(ctx context.Context, search string) (int, error)
+	GetAll(ctx context.Context, search string, sort models.Sort, limit int, page int) ([]models.BlogTag, models.PageMeta, error)
+	GetAllWithCursor(ctx context.Context, search string, sort models.Sort, cursor *models.Cursor, limit int) ([]models.BlogTag, models.Cursors, error)
+	Create(ctx context.Context, input *models.BlogTag) (models.BlogTag, error)
+	Update(ctx context.Context, input *models.BlogTag) (models.BlogTag, error)
+	Remove(ctx context.Context, id string) (string, error)
+}
+
+// SeriesRepository is the storage used by the blog series controller.
+// BlogSeriesService implements it on top of Postgres and
+// MemoryBlogSeriesService keeps everything in process memory.
+type SeriesRepository interface {
+	GetAll(ctx context.Context) ([]models.BlogSeries, error)
+	GetWithSlug(ctx context.Context, slug string, status string) (models.BlogSeriesWithPosts, error)
+	Create(ctx context.Context, input *models.BlogSeriesCreated) (models.BlogSeriesWithPosts, error)
+	Update(ctx context.Context, input *models.BlogSeriesUpdated) (models.BlogSeriesWithPosts, error)
+	Remove(ctx context.Context, id string) (string, error)
+}
+
+// RevisionRepository reads the revisions saved by PostRepository.Update
+type RevisionRepository interface {
+	GetAll(ctx context.Context, postId string) ([]models.BlogPostRevision, error)
+	Get(ctx context.Context, postId string, id string) (models.BlogPostRevisionContent, error)
+}
+
+var (
+	_ PostRepository = (*BlogPostService)(nil)
+	_ PostRepository = (*MemoryBlogPostService)(nil)
+	_ TagRepository  = (*BlogTagService)(nil)
+	_ TagRepository  = (*MemoryBlogTagService)(nil)
+
+	_ RevisionRepository = (*BlogPostRevisionService)(nil)
+	_ RevisionRepository = (*MemoryBlogPostRevisionService)(nil)
+
+	_ SeriesRepository = (*BlogSeriesService)(nil)
+	_ SeriesRepository = (*MemoryBlogSeriesService)(nil)
+)
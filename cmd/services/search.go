@@ -0,0 +1,40 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Marks put around the matched words by ts_headline, they become <mark>
+// elements once the snippet is escaped
+const (
+	markStart = "\x02"
+	markStop  = "\x03"
+)
+
+// headlineOptions of ts_headline for the snippets of search results
+var headlineOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`, markStart, markStop)
+
+// searchVector returns the SQL tsvector of a post made from the title and
+// content expressions, words of the title rank above words of the content
+func searchVector(title string, content string) string {
+	return fmt.Sprintf("setweight(to_tsvector(@language::regconfig, %s), 'A') || setweight(to_tsvector(@language::regconfig, %s), 'B')", title, content)
+}
+
+// searchCondition returns the SQL condition keeping the posts matching a
+// search in the websearch_to_tsquery syntax, with "quoted phrases", OR and
+// -excluded words. An empty search keeps every post.
+func searchCondition(search string) string {
+	if search == "" {
+		return "TRUE"
+	}
+	return "blog_post.search_vector @@ websearch_to_tsquery(@language::regconfig, @search)"
+}
+
+// markSnippet escapes a snippet of ts_headline for HTML and puts <mark>
+// elements around the matched words
+func markSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(snippet)
+}
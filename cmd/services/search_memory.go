@@ -0,0 +1,160 @@
+package services
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// memorySnippetLength is about the number of characters of a snippet
+const memorySnippetLength = 240
+
+// memoryQuery is a search parsed like websearch_to_tsquery does: groups
+// joined by OR, a post matches a group when it has every word and phrase
+// of the group and none of its excluded ones. Words are matched without
+// stemming, as parts of words ignoring case.
+type memoryQuery [][]memoryTerm
+
+type memoryTerm struct {
+	text    string
+	exclude bool
+}
+
+func parseMemoryQuery(search string) memoryQuery {
+	value := memoryQuery{}
+	group := []memoryTerm{}
+	rest := strings.TrimSpace(search)
+	for rest != "" {
+		exclude := strings.HasPrefix(rest, "-")
+		if exclude {
+			rest = rest[1:]
+		}
+
+		// Read a quoted phrase or a word
+		text := ""
+		quoted := strings.HasPrefix(rest, `"`)
+		if quoted {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				text, rest = rest[1:], ""
+			} else {
+				text, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if end := strings.IndexFunc(rest, isSpace); end < 0 {
+			text, rest = rest, ""
+		} else {
+			text, rest = rest[:end], rest[end:]
+		}
+		rest = strings.TrimSpace(rest)
+
+		text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+		switch {
+		case text == "":
+		case text == "or" && !quoted && !exclude:
+			if len(group) > 0 {
+				value = append(value, group)
+				group = []memoryTerm{}
+			}
+		default:
+			group = append(group, memoryTerm{text: text, exclude: exclude})
+		}
+	}
+	if len(group) > 0 {
+		value = append(value, group)
+	}
+	return value
+}
+
+func isSpace(r rune) bool {
+	return strings.ContainsRune(" \t\r\n", r)
+}
+
+// rank tells whether a post matches the query and how well, words of the
+// title count more than words of the content like the weights of the
+// search_vector
+func (q memoryQuery) rank(title string, content string) (float64, bool) {
+	if len(q) == 0 {
+		return 0, true
+	}
+	title, content = strings.ToLower(title), strings.ToLower(content)
+
+	matched := false
+	value := 0.0
+	for _, group := range q {
+		groupMatched := true
+		for _, term := range group {
+			found := strings.Contains(title, term.text) || strings.Contains(content, term.text)
+			if found == term.exclude {
+				groupMatched = false
+				break
+			}
+		}
+		if !groupMatched {
+			continue
+		}
+		matched = true
+		for _, term := range group {
+			if !term.exclude {
+				value += float64(4*strings.Count(title, term.text) + strings.Count(content, term.text))
+			}
+		}
+	}
+	return value, matched
+}
+
+// snippet returns the part of content around the first matched word, HTML
+// escaped with <mark> elements around the matched words like markSnippet
+func (q memoryQuery) snippet(content string) string {
+	terms := []string{}
+	for _, group := range q {
+		for _, term := range group {
+			if !term.exclude {
+				terms = append(terms, regexp.QuoteMeta(term.text))
+			}
+		}
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	matcher := regexp.MustCompile(`(?i)` + strings.Join(terms, "|"))
+
+	// Start a little before the first match, on a word
+	start := 0
+	if first := matcher.FindStringIndex(content); first != nil && first[0] > memorySnippetLength/3 {
+		start = first[0] - memorySnippetLength/3
+		if space := strings.IndexFunc(content[start:first[0]], isSpace); space >= 0 {
+			start += space + 1
+		}
+		for start < len(content) && !utf8.RuneStart(content[start]) {
+			start++
+		}
+	}
+	end := len(content)
+	if end-start > memorySnippetLength {
+		end = start + memorySnippetLength
+		if space := strings.LastIndexFunc(content[start:end], isSpace); space > 0 {
+			end = start + space
+		}
+		for end > start && !utf8.RuneStart(content[end]) {
+			end--
+		}
+	}
+	window := content[start:end]
+
+	value := strings.Builder{}
+	if start > 0 {
+		value.WriteString("… ")
+	}
+	last := 0
+	for _, match := range matcher.FindAllStringIndex(window, -1) {
+		value.WriteString(html.EscapeString(window[last:match[0]]))
+		value.WriteString("<mark>" + html.EscapeString(window[match[0]:match[1]]) + "</mark>")
+		last = match[1]
+	}
+	value.WriteString(html.EscapeString(window[last:]))
+	if end < len(content) {
+		value.WriteString(" …")
+	}
+	return strings.Join(strings.Fields(value.String()), " ")
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryQuery(t *testing.T) {
+	t.Run("Parse success", func(t *testing.T) {
+		assert.Equal(t, memoryQuery{
+			{{text: "go"}, {text: "error handling"}, {text: "java", exclude: true}},
+			{{text: "rust"}},
+		}, parseMemoryQuery(`Go "error  handling" -java or Rust`))
+		assert.Equal(t, memoryQuery{{{text: "unclosed phrase"}}}, parseMemoryQuery(`"unclosed phrase`))
+		assert.Equal(t, memoryQuery{}, parseMemoryQuery("  or  "))
+	})
+
+	t.Run("Rank success", func(t *testing.T) {
+		tests := []struct {
+			search  string
+			matched bool
+		}{
+			{"", true},
+			{"channels", true},
+			{`"go channels"`, false},
+			{`"channels in go"`, true},
+			{"channels -mutex", false},
+			{"select -mutex", false},
+			{"java or select", true},
+			{"java or python", false},
+		}
+		for _, test := range tests {
+			_, matched := parseMemoryQuery(test.search).rank("Channels in Go", "Use select and a mutex.")
+			assert.Equal(t, test.matched, matched, test.search)
+		}
+
+		inTitle, _ := parseMemoryQuery("go").rank("Go", "")
+		inContent, _ := parseMemoryQuery("go").rank("", "go")
+		assert.Greater(t, inTitle, inContent)
+	})
+
+	t.Run("Snippet success", func(t *testing.T) {
+		query := parseMemoryQuery("channels -mutex")
+		assert.Equal(t, "Use <mark>channels</mark> &lt;here&gt;.", query.snippet("Use channels <here>."))
+		assert.Equal(t, "", parseMemoryQuery("-mutex").snippet("Use a mutex."))
+
+		// Long content is cut around the first match
+		value := query.snippet(strings.Repeat("before ", 50) + "Channels " + strings.Repeat("after ", 50))
+		assert.True(t, strings.HasPrefix(value, "… before"))
+		assert.Contains(t, value, "<mark>Channels</mark>")
+		assert.True(t, strings.HasSuffix(value, "after …"))
+	})
+}
+
+func Test_MarkSnippet(t *testing.T) {
+	assert.Equal(t, "a <mark>go</mark> &amp; b", markSnippet("a "+markStart+"go"+markStop+" & b"))
+}
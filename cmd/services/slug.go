@@ -0,0 +1,105 @@
+package services
+
+import (
+	"api-chi/internal/validate"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gosimple/slug"
+	"github.com/jackc/pgx/v5"
+)
+
+// slugLockId is the first key of the advisory locks taken while choosing
+// a slug, the second one is a hash of the slug
+const slugLockId = 5003_0003
+
+// baseSlug returns the slug made from a title, titles without any letter
+// or digit get a generic one
+func baseSlug(title string) string {
+	value := slug.Make(title)
+	if value == "" {
+		return "post"
+	}
+	return value
+}
+
+// pickSlug returns the first slug among base, base-2, base-3... that is
+// neither reserved nor taken. current is the slug the post has now, it is
+// kept when it is one of them so that saving a post doesn't renumber it.
+func pickSlug(base string, current string, taken func(slug string) bool) string {
+	if (current == base && !validate.IsReservedSlug(base)) || isNumberedSlug(base, current) {
+		return current
+	}
+
+	if !validate.IsReservedSlug(base) && !taken(base) {
+		return base
+	}
+	for n := 2; ; n++ {
+		candidate := base + "-" + strconv.Itoa(n)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// isNumberedSlug reports whether slug is one of base-2, base-3...
+func isNumberedSlug(base string, slug string) bool {
+	number, found := strings.CutPrefix(slug, base+"-")
+	if !found {
+		return false
+	}
+	n, err := strconv.Atoi(number)
+	return err == nil && n >= 2 && strconv.Itoa(n) == number
+}
+
+// IsTitleSlug reports whether slug was made from title, numbered or not,
+// rather than chosen by hand
+func IsTitleSlug(slug string, title string) bool {
+	base := baseSlug(title)
+	return slug == base || isNumberedSlug(base, slug)
+}
+
+// updateSlug returns the custom slug to update a post with. Without one the
+// slug follows the title only when it was made from the previous title,
+// custom slugs are kept.
+func updateSlug(custom string, current string, previousTitle string) string {
+	if custom != "" || IsTitleSlug(current, previousTitle) {
+		return custom
+	}
+	return current
+}
+
+// chooseSlug returns the slug to save a post with inside tx. A custom slug
+// is used as is, a slug taken by another post then fails on the unique
+// constraint. Otherwise the slug is made from the title and numbered when
+// it collides. current is the slug of the post being updated, empty for
+// new posts.
+func chooseSlug(ctx context.Context, tx pgx.Tx, title string, custom string, current string) (string, error) {
+	if custom != "" {
+		return custom, nil
+	}
+	base := baseSlug(title)
+
+	// Posts with the same base slug are saved one at a time
+	lockSql := "SELECT pg_advisory_xact_lock(@lock_id, hashtext(@slug));"
+	if _, err := tx.Exec(ctx, lockSql, pgx.NamedArgs{"lock_id": slugLockId, "slug": base}); err != nil {
+		return "", err
+	}
+
+	takenSql := "SELECT slug FROM blog_post WHERE slug = @slug OR slug LIKE @slug || '-%';"
+	rows, err := tx.Query(ctx, takenSql, pgx.NamedArgs{"slug": base})
+	if err != nil {
+		return "", err
+	}
+	slugs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return "", err
+	}
+
+	taken := map[string]bool{}
+	for _, value := range slugs {
+		taken[value] = value != current
+	}
+	return pickSlug(base, current, func(slug string) bool { return taken[slug] }), nil
+}
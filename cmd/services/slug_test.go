@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_pickSlug(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		current string
+		taken   []string
+		want    string
+	}{
+		{name: "Free", base: "my-title", want: "my-title"},
+		{name: "Taken", base: "my-title", taken: []string{"my-title"}, want: "my-title-2"},
+		{name: "Taken twice", base: "my-title", taken: []string{"my-title", "my-title-2"}, want: "my-title-3"},
+		{name: "Gap", base: "my-title", taken: []string{"my-title", "my-title-3"}, want: "my-title-2"},
+		{name: "Reserved", base: "archive", want: "archive-2"},
+		{name: "Keep current", base: "my-title", current: "my-title-3", want: "my-title-3"},
+		{name: "Keep current base", base: "my-title", current: "my-title", want: "my-title"},
+		{name: "Current of another title", base: "my-title", current: "my-title-two", taken: []string{"my-title"}, want: "my-title-2"},
+		{name: "Current with leading zero", base: "my-title", current: "my-title-02", want: "my-title"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			taken := func(slug string) bool {
+				for _, value := range test.taken {
+					if value == slug {
+						return true
+					}
+				}
+				return false
+			}
+			assert.Equal(t, test.want, pickSlug(test.base, test.current, taken))
+		})
+	}
+
+	t.Run("Title without letters", func(t *testing.T) {
+		assert.Equal(t, "post", baseSlug("!!!"))
+		assert.Equal(t, "my-title", baseSlug("My Title"))
+	})
+}
+
+func Test_updateSlug(t *testing.T) {
+	tests := []struct {
+		name          string
+		custom        string
+		current       string
+		previousTitle string
+		want          string
+	}{
+		{name: "Custom", custom: "new-slug", current: "my-title", previousTitle: "My title", want: "new-slug"},
+		{name: "Made from title", current: "my-title", previousTitle: "My title", want: ""},
+		{name: "Numbered from title", current: "my-title-2", previousTitle: "My title", want: ""},
+		{name: "Kept custom", current: "my-own-slug", previousTitle: "My title", want: "my-own-slug"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, updateSlug(test.custom, test.current, test.previousTitle))
+		})
+	}
+}
+
+func Test_IsTitleSlug(t *testing.T) {
+	assert.True(t, IsTitleSlug("my-title", "My title"))
+	assert.True(t, IsTitleSlug("my-title-2", "My title"))
+	assert.True(t, IsTitleSlug("post", "!!!"))
+	assert.False(t, IsTitleSlug("my-own-slug", "My title"))
+	assert.False(t, IsTitleSlug("my-title-02", "My title"))
+}
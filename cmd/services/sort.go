@@ -0,0 +1,72 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"slices"
+	"strings"
+)
+
+// sortColumn is what a sort field orders by in SQL, the types the values
+// are cast back to from cursors and its usual direction
+type sortColumn struct {
+	exprs []string
+	types []string
+	order string
+}
+
+// postSortColumns and tagSortColumns are the only SQL a sort is made of,
+// query is the websearch_to_tsquery of the post listings
+var postSortColumns = map[string]sortColumn{
+	models.SORT_CREATED_AT: {[]string{"blog_post.created_at"}, []string{"timestamptz"}, models.ORDER_DESC},
+	models.SORT_UPDATED_AT: {[]string{"blog_post.updated_at"}, []string{"timestamptz"}, models.ORDER_DESC},
+	models.SORT_TITLE:      {[]string{"blog_post.title"}, []string{"text"}, models.ORDER_ASC},
+	models.SORT_RELEVANCE:  {[]string{"ts_rank_cd(blog_post.search_vector, query)", "blog_post.created_at"}, []string{"real", "timestamptz"}, models.ORDER_DESC},
+}
+
+var tagSortColumns = map[string]sortColumn{
+	models.SORT_NAME:  {[]string{"blog_tag.name"}, []string{"text"}, models.ORDER_ASC},
+	models.SORT_USAGE: {[]string{"(SELECT COUNT(*) FROM blog_post_tag WHERE blog_post_tag.tag_id = blog_tag.id)"}, []string{"bigint"}, models.ORDER_DESC},
+}
+
+// postSort returns the sort of a post listing with the defaults applied,
+// most relevant first when searching and newest first otherwise
+func postSort(sort models.Sort, search string) models.Sort {
+	if _, ok := postSortColumns[sort.Field]; !ok {
+		sort.Field = models.SORT_CREATED_AT
+		if search != "" {
+			sort.Field = models.SORT_RELEVANCE
+		}
+	}
+	return withOrder(sort, postSortColumns)
+}
+
+// tagSort returns the sort of a tag listing with the defaults applied
+func tagSort(sort models.Sort) models.Sort {
+	if _, ok := tagSortColumns[sort.Field]; !ok {
+		sort.Field = models.SORT_NAME
+	}
+	return withOrder(sort, tagSortColumns)
+}
+
+func withOrder(sort models.Sort, columns map[string]sortColumn) models.Sort {
+	if sort.Order != models.ORDER_ASC && sort.Order != models.ORDER_DESC {
+		sort.Order = columns[sort.Field].order
+	}
+	return sort
+}
+
+// orderBy returns the ORDER BY clause of a sort made by postSort or tagSort.
+// Only whitelisted columns and directions are written, the id ends it so
+// that rows with equal values keep the same order from page to page.
+func orderBy(sort models.Sort, columns map[string]sortColumn, id string) string {
+	direction := " ASC"
+	if sort.Order == models.ORDER_DESC {
+		direction = " DESC"
+	}
+
+	parts := []string{}
+	for _, expr := range append(slices.Clone(columns[sort.Field].exprs), id) {
+		parts = append(parts, expr+direction)
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sortPosts orders posts like orderBy does with postSortColumns, ranks
+// holds the relevance of the posts by id
+func sortPosts(posts []models.BlogPostContentWithTags, sort models.Sort, ranks map[string]float64) {
+	slices.SortFunc(posts, func(a, b models.BlogPostContentWithTags) int {
+		return comparePosts(a, ranks[a.Id], b, ranks[b.Id], sort)
+	})
+}
+
+func comparePosts(a models.BlogPostContentWithTags, rankA float64, b models.BlogPostContentWithTags, rankB float64, sort models.Sort) int {
+	value := 0
+	switch sort.Field {
+	case models.SORT_UPDATED_AT:
+		value = a.UpdatedAt.Compare(b.UpdatedAt)
+	case models.SORT_TITLE:
+		value = strings.Compare(a.Title, b.Title)
+	case models.SORT_RELEVANCE:
+		value = cmp.Or(cmp.Compare(rankA, rankB), a.CreatedAt.Compare(b.CreatedAt))
+	default:
+		value = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	return withDirection(cmp.Or(value, strings.Compare(a.Id, b.Id)), sort)
+}
+
+// postKeys returns the keys of a post like cursorKeysSql does with
+// postSortColumns
+func postKeys(post models.BlogPostContentWithTags, rank float64, sort models.Sort) []string {
+	switch sort.Field {
+	case models.SORT_UPDATED_AT:
+		return []string{post.UpdatedAt.Format(time.RFC3339Nano), post.Id}
+	case models.SORT_TITLE:
+		return []string{post.Title, post.Id}
+	case models.SORT_RELEVANCE:
+		return []string{strconv.FormatFloat(rank, 'g', -1, 64), post.CreatedAt.Format(time.RFC3339Nano), post.Id}
+	default:
+		return []string{post.CreatedAt.Format(time.RFC3339Nano), post.Id}
+	}
+}
+
+// postFromKeys returns a post with the values of keys made by postKeys,
+// to compare posts with
+func postFromKeys(keys []string, sort models.Sort) (models.BlogPostContentWithTags, float64, error) {
+	value := models.BlogPostContentWithTags{}
+	rank := 0.0
+	var err error
+	if len(keys) != len(postSortColumns[sort.Field].exprs)+1 {
+		return value, rank, fmt.Errorf("%w: %d keys for sort %q", ErrInvalidCursor, len(keys), sort.Field)
+	}
+
+	value.Id = keys[len(keys)-1]
+	switch sort.Field {
+	case models.SORT_UPDATED_AT:
+		value.UpdatedAt, err = time.Parse(time.RFC3339Nano, keys[0])
+	case models.SORT_TITLE:
+		value.Title = keys[0]
+	case models.SORT_RELEVANCE:
+		rank, err = strconv.ParseFloat(keys[0], 64)
+		if err == nil {
+			value.CreatedAt, err = time.Parse(time.RFC3339Nano, keys[1])
+		}
+	default:
+		value.CreatedAt, err = time.Parse(time.RFC3339Nano, keys[0])
+	}
+	if err != nil {
+		return value, rank, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return value, rank, nil
+}
+
+// tagUsage returns the number of posts of every tag by id
+func (s *MemoryStore) tagUsage() map[string]int {
+	value := map[string]int{}
+	for _, link := range s.postTags {
+		value[link.tagId]++
+	}
+	return value
+}
+
+// sortTags orders tags like orderBy does with tagSortColumns
+func (s *MemoryStore) sortTags(tags []models.BlogTag, sort models.Sort) {
+	usage := s.tagUsage()
+	slices.SortFunc(tags, func(a, b models.BlogTag) int {
+		return compareTags(a, usage[a.Id], b, usage[b.Id], sort)
+	})
+}
+
+func compareTags(a models.BlogTag, usageA int, b models.BlogTag, usageB int, sort models.Sort) int {
+	value := 0
+	switch sort.Field {
+	case models.SORT_USAGE:
+		value = cmp.Compare(usageA, usageB)
+	default:
+		value = strings.Compare(a.Name, b.Name)
+	}
+	return withDirection(cmp.Or(value, strings.Compare(a.Id, b.Id)), sort)
+}
+
+// tagKeys returns the keys of a tag like cursorKeysSql does with
+// tagSortColumns
+func tagKeys(tag models.BlogTag, usage int, sort models.Sort) []string {
+	if sort.Field == models.SORT_USAGE {
+		return []string{strconv.Itoa(usage), tag.Id}
+	}
+	return []string{tag.Name, tag.Id}
+}
+
+// tagFromKeys returns a tag with the values of keys made by tagKeys
+func tagFromKeys(keys []string, sort models.Sort) (models.BlogTag, int, error) {
+	value := models.BlogTag{}
+	usage := 0
+	if len(keys) != 2 {
+		return value, usage, fmt.Errorf("%w: %d keys for sort %q", ErrInvalidCursor, len(keys), sort.Field)
+	}
+
+	value.Id = keys[1]
+	if sort.Field != models.SORT_USAGE {
+		value.Name = keys[0]
+		return value, usage, nil
+	}
+	usage, err := strconv.Atoi(keys[0])
+	if err != nil {
+		return value, usage, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return value, usage, nil
+}
+
+func withDirection(value int, sort models.Sort) int {
+	if sort.Order == models.ORDER_DESC {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"api-chi/cmd/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OrderBy(t *testing.T) {
+	t.Run("Post sort success", func(t *testing.T) {
+		tests := []struct {
+			sort   models.Sort
+			search string
+			sql    string
+		}{
+			{models.Sort{}, "", " ORDER BY blog_post.created_at DESC, blog_post.id DESC"},
+			{models.Sort{}, "go", " ORDER BY ts_rank_cd(blog_post.search_vector, query) DESC, blog_post.created_at DESC, blog_post.id DESC"},
+			{models.Sort{Field: models.SORT_TITLE}, "", " ORDER BY blog_post.title ASC, blog_post.id ASC"},
+			{models.Sort{Field: models.SORT_UPDATED_AT, Order: models.ORDER_ASC}, "", " ORDER BY blog_post.updated_at ASC, blog_post.id ASC"},
+		}
+		for _, test := range tests {
+			assert.Equal(t, test.sql, orderBy(postSort(test.sort, test.search), postSortColumns, "blog_post.id"))
+		}
+	})
+
+	t.Run("Unknown fields and orders are never written", func(t *testing.T) {
+		sort := postSort(models.Sort{Field: "id; DROP TABLE blog_post", Order: "sideways"}, "")
+		assert.Equal(t, models.Sort{Field: models.SORT_CREATED_AT, Order: models.ORDER_DESC}, sort)
+
+		sort = tagSort(models.Sort{Field: models.SORT_TITLE})
+		assert.Equal(t, models.Sort{Field: models.SORT_NAME, Order: models.ORDER_ASC}, sort)
+		assert.Equal(t, " ORDER BY blog_tag.name ASC, blog_tag.id ASC", orderBy(sort, tagSortColumns, "blog_tag.id"))
+	})
+}
@@ -0,0 +1,16 @@
+package services
+
+import (
+	"api-chi/internal/markdown"
+	"strings"
+)
+
+// postStats returns the excerpt, word count and reading time stored with
+// a post. The summary of the author, when given, is the excerpt.
+func postStats(summary string, content string) markdown.Stats {
+	value := markdown.Measure(content)
+	if summary = strings.TrimSpace(summary); summary != "" {
+		value.Excerpt = summary
+	}
+	return value
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"api-chi/cmd/config"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = "usage: api-chi config print"
+
+// runConfig runs the "api-chi config" subcommand
+func runConfig(w io.Writer, configFile string, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	cfg, err := config.Read(configFile)
+	if err != nil {
+		return err
+	}
+
+	// Print the effective config even when invalid, then report why
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg.Redacted()); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+	return nil
+}
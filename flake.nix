@@ -21,7 +21,6 @@
               nativeBuildInputs = [
                 # Golang
                 go
-                goose # Database migration
                 golangci-lint
               ];
             };
@@ -0,0 +1,143 @@
+package diff
+
+import "strings"
+
+// Operations of a diff line
+const (
+	EQUAL  = "equal"
+	INSERT = "insert"
+	DELETE = "delete"
+)
+
+// MAX_EDITS bounds the work done on very different texts, past it the
+// changed part is reported as deleted then inserted as a whole
+const MAX_EDITS = 1000
+
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines returns the line-level diff turning a into b, using the Myers
+// algorithm so that the fewest lines are marked as changed
+func Lines(a string, b string) []Line {
+	x := splitLines(a)
+	y := splitLines(b)
+
+	// Lines kept at both ends don't take part in the search
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix += 1
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix += 1
+	}
+
+	value := []Line{}
+	for _, text := range x[:prefix] {
+		value = append(value, Line{Op: EQUAL, Text: text})
+	}
+	value = append(value, middle(x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+	for _, text := range x[len(x)-suffix:] {
+		value = append(value, Line{Op: EQUAL, Text: text})
+	}
+	return value
+}
+
+// splitLines splits text on line breaks, an empty text has no line
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+func middle(x []string, y []string) []Line {
+	n, m := len(x), len(y)
+	if n == 0 && m == 0 {
+		return []Line{}
+	}
+
+	// v[offset+k] is the furthest index in x reached on diagonal k, trace
+	// keeps the diagonals -d-1 to d+1 of v before each round d to walk the
+	// path back
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	trace := [][]int{}
+	for d := 0; d <= n+m; d++ {
+		if d > MAX_EDITS {
+			return replace(x, y)
+		}
+		trace = append(trace, append([]int{}, v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			i := 0
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				i = v[offset+k+1]
+			} else {
+				i = v[offset+k-1] + 1
+			}
+			j := i - k
+			for i < n && j < m && x[i] == y[j] {
+				i += 1
+				j += 1
+			}
+			v[offset+k] = i
+
+			if i >= n && j >= m {
+				return backtrack(x, y, trace)
+			}
+		}
+	}
+	return replace(x, y)
+}
+
+func backtrack(x []string, y []string, trace [][]int) []Line {
+	// Lines are collected from the end then reversed
+	reversed := []Line{}
+	i, j := len(x), len(y)
+	for d := len(trace) - 1; d >= 0; d-- {
+		// v[d+1+k] is diagonal k before round d
+		v := trace[d]
+		k := i - j
+
+		prevK := k - 1
+		if k == -d || (k != d && v[d+k] < v[d+k+2]) {
+			prevK = k + 1
+		}
+		prevI := v[d+1+prevK]
+		prevJ := prevI - prevK
+
+		for i > prevI && j > prevJ {
+			reversed = append(reversed, Line{Op: EQUAL, Text: x[i-1]})
+			i -= 1
+			j -= 1
+		}
+		if d > 0 {
+			if i == prevI {
+				reversed = append(reversed, Line{Op: INSERT, Text: y[j-1]})
+			} else {
+				reversed = append(reversed, Line{Op: DELETE, Text: x[i-1]})
+			}
+		}
+		i, j = prevI, prevJ
+	}
+
+	value := make([]Line, len(reversed))
+	for n, line := range reversed {
+		value[len(reversed)-1-n] = line
+	}
+	return value
+}
+
+func replace(x []string, y []string) []Line {
+	value := []Line{}
+	for _, text := range x {
+		value = append(value, Line{Op: DELETE, Text: text})
+	}
+	for _, text := range y {
+		value = append(value, Line{Op: INSERT, Text: text})
+	}
+	return value
+}
@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// apply returns the texts the diff turns from and into
+func apply(lines []Line) (string, string) {
+	from := []string{}
+	to := []string{}
+	for _, line := range lines {
+		if line.Op != INSERT {
+			from = append(from, line.Text)
+		}
+		if line.Op != DELETE {
+			to = append(to, line.Text)
+		}
+	}
+	return strings.Join(from, "\n"), strings.Join(to, "\n")
+}
+
+// lcs returns the length of the longest common subsequence of lines
+func lcs(x []string, y []string) int {
+	table := make([][]int, len(x)+1)
+	for i := range table {
+		table[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
+
+func Test_Lines(t *testing.T) {
+	t.Run("Lines success", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			a     string
+			b     string
+			lines []Line
+		}{
+			{"empty", "", "", []Line{}},
+			{"same", "a\nb", "a\nb", []Line{{EQUAL, "a"}, {EQUAL, "b"}}},
+			{"insert", "", "a", []Line{{INSERT, "a"}}},
+			{"delete", "a", "", []Line{{DELETE, "a"}}},
+			{"change middle", "a\nb\nc", "a\nx\nc", []Line{{EQUAL, "a"}, {DELETE, "b"}, {INSERT, "x"}, {EQUAL, "c"}}},
+			{"move", "a\nb\nc", "b\nc\na", []Line{{DELETE, "a"}, {EQUAL, "b"}, {EQUAL, "c"}, {INSERT, "a"}}},
+			{"crlf", "a\r\nb", "a\nb", []Line{{EQUAL, "a"}, {EQUAL, "b"}}},
+		}
+		for _, test := range tests {
+			assert.Equal(t, test.lines, Lines(test.a, test.b), test.name)
+		}
+	})
+
+	t.Run("Lines is minimal", func(t *testing.T) {
+		random := rand.New(rand.NewSource(1))
+		text := func() string {
+			lines := make([]string, random.Intn(12))
+			for i := range lines {
+				lines[i] = string(rune('a' + random.Intn(4)))
+			}
+			return strings.Join(lines, "\n")
+		}
+
+		for range 500 {
+			a, b := text(), text()
+			lines := Lines(a, b)
+
+			from, to := apply(lines)
+			assert.Equal(t, a, from)
+			assert.Equal(t, b, to)
+
+			changed := 0
+			for _, line := range lines {
+				if line.Op != EQUAL {
+					changed += 1
+				}
+			}
+			x, y := splitLines(a), splitLines(b)
+			assert.Equal(t, len(x)+len(y)-2*lcs(x, y), changed, "%q -> %q", a, b)
+		}
+	})
+
+	t.Run("Lines replaces very different texts", func(t *testing.T) {
+		a := make([]string, MAX_EDITS)
+		b := make([]string, MAX_EDITS)
+		for i := range a {
+			a[i] = "a"
+			b[i] = "b"
+		}
+
+		lines := Lines(strings.Join(a, "\n"), strings.Join(b, "\n"))
+		assert.Equal(t, 2*MAX_EDITS, len(lines))
+		assert.Equal(t, DELETE, lines[0].Op)
+		assert.Equal(t, INSERT, lines[len(lines)-1].Op)
+	})
+}
@@ -0,0 +1,148 @@
+package markdown
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// CACHE_SIZE is the number of rendered documents kept by default
+const CACHE_SIZE = 256
+
+// Heading is an entry of the table of contents, Id is the id attribute of
+// the heading in the HTML so that it can be linked to
+type Heading struct {
+	Level int    `json:"level"`
+	Id    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+// Document is Markdown rendered to sanitized HTML. Code blocks keep their
+// language as a language-* class on the code element for client side
+// highlighters.
+type Document struct {
+	Html string    `json:"html"`
+	Toc  []Heading `json:"toc"`
+}
+
+// Renderer turns Markdown into a Document and caches the result by content,
+// so that a post is rendered again only once it changes. It is safe for
+// concurrent use, the returned documents must not be modified.
+type Renderer struct {
+	markdown goldmark.Markdown
+	policy   *bluemonday.Policy
+
+	mu      sync.Mutex
+	size    int
+	entries map[[sha256.Size]byte]Document
+	order   [][sha256.Size]byte
+}
+
+func NewRenderer(size int) *Renderer {
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+
+	return &Renderer{
+		markdown: goldmark.New(
+			goldmark.WithExtensions(extension.GFM),
+			goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		),
+		policy:  policy,
+		size:    size,
+		entries: map[[sha256.Size]byte]Document{},
+	}
+}
+
+func (r *Renderer) Render(source string) (Document, error) {
+	key := sha256.Sum256([]byte(source))
+
+	r.mu.Lock()
+	value, ok := r.entries[key]
+	r.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+
+	value, err := r.render([]byte(source))
+	if err != nil {
+		return value, err
+	}
+
+	// Forget the oldest documents once the cache is full
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[key]; !ok && r.size > 0 {
+		for len(r.order) >= r.size {
+			delete(r.entries, r.order[0])
+			r.order = r.order[1:]
+		}
+		r.entries[key] = value
+		r.order = append(r.order, key)
+	}
+	return value, nil
+}
+
+func (r *Renderer) render(source []byte) (Document, error) {
+	doc := r.markdown.Parser().Parse(text.NewReader(source))
+
+	// Build the table of contents from the headings
+	toc := []Heading{}
+	err := ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := node.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		id, _ := heading.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		toc = append(toc, Heading{
+			Level: heading.Level,
+			Id:    string(idBytes),
+			Text:  nodeText(heading, source),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return Document{}, err
+	}
+
+	html := bytes.Buffer{}
+	if err := r.markdown.Renderer().Render(&html, source, doc); err != nil {
+		return Document{}, err
+	}
+
+	return Document{
+		Html: string(r.policy.SanitizeBytes(html.Bytes())),
+		Toc:  toc,
+	}, nil
+}
+
+// nodeText returns the plain text inside a node, without the markup
+func nodeText(node ast.Node, source []byte) string {
+	value := strings.Builder{}
+	_ = ast.Walk(node, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch child := child.(type) {
+		case *ast.Text:
+			value.Write(child.Segment.Value(source))
+			if child.SoftLineBreak() || child.HardLineBreak() {
+				value.WriteByte(' ')
+			}
+		case *ast.String:
+			value.Write(child.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(value.String())
+}
@@ -0,0 +1,53 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Renderer(t *testing.T) {
+	renderer := NewRenderer(2)
+
+	t.Run("Render success", func(t *testing.T) {
+		value, err := renderer.Render("## Hello new post!\n\nSome *text*.\n\n### Part `one`\n\n```go\nfmt.Println(\"hi\")\n```\n")
+		assert.NoError(t, err)
+		assert.Contains(t, value.Html, `<h2 id="hello-new-post">Hello new post!</h2>`)
+		assert.Contains(t, value.Html, `<p>Some <em>text</em>.</p>`)
+		assert.Contains(t, value.Html, `<pre><code class="language-go">fmt.Println(&#34;hi&#34;)`)
+		assert.Equal(t, []Heading{
+			{Level: 2, Id: "hello-new-post", Text: "Hello new post!"},
+			{Level: 3, Id: "part-one", Text: "Part one"},
+		}, value.Toc)
+	})
+
+	t.Run("Render sanitizes HTML", func(t *testing.T) {
+		value, err := renderer.Render("[link](javascript:alert(1))\n\n<script>alert(1)</script>\n\n<img src=x onerror=alert(1)>\n\n```\"><script>\nx\n```\n")
+		assert.NoError(t, err)
+		assert.NotContains(t, value.Html, "<script")
+		assert.NotContains(t, value.Html, "javascript:")
+		assert.NotContains(t, value.Html, "onerror")
+		assert.NotContains(t, value.Html, `class="language-`)
+	})
+
+	t.Run("Render caches by content", func(t *testing.T) {
+		renderer := NewRenderer(2)
+		first, err := renderer.Render("# One")
+		assert.NoError(t, err)
+		_, err = renderer.Render("# One")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(renderer.entries))
+
+		// The oldest document is dropped once the cache is full
+		_, err = renderer.Render("# Two")
+		assert.NoError(t, err)
+		_, err = renderer.Render("# Three")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(renderer.entries))
+		assert.Equal(t, 2, len(renderer.order))
+
+		again, err := renderer.Render("# One")
+		assert.NoError(t, err)
+		assert.Equal(t, first, again)
+	})
+}
@@ -0,0 +1,90 @@
+package markdown
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// EXCERPT_MAX_LENGTH is the number of characters an excerpt is cut at
+const EXCERPT_MAX_LENGTH = 200
+
+// WORDS_PER_MINUTE is the reading speed used for the reading time
+const WORDS_PER_MINUTE = 200
+
+// Stats describe the text of a post for cards and lists
+type Stats struct {
+	Excerpt            string
+	WordCount          int
+	ReadingTimeMinutes int
+}
+
+var textParser = goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser()
+
+// Measure returns the stats of Markdown source. The excerpt is made from
+// the first paragraphs, or from any text when there is no paragraph.
+func Measure(source string) Stats {
+	src := []byte(source)
+	doc := textParser.Parse(text.NewReader(src))
+
+	words := 0
+	paragraphs := []string{}
+	blocks := []string{}
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := node.(type) {
+		case *ast.Paragraph, *ast.TextBlock:
+			value := nodeText(node, src)
+			paragraphs = append(paragraphs, value)
+			blocks = append(blocks, value)
+			words += len(strings.Fields(value))
+			return ast.WalkSkipChildren, nil
+		case *ast.Heading:
+			value := nodeText(node, src)
+			blocks = append(blocks, value)
+			words += len(strings.Fields(value))
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeBlock, *ast.FencedCodeBlock:
+			// Code is read as well but never shown in an excerpt
+			lines := node.Lines()
+			for i := range lines.Len() {
+				segment := lines.At(i)
+				words += len(strings.Fields(string(segment.Value(src))))
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	if len(paragraphs) == 0 {
+		paragraphs = blocks
+	}
+	return Stats{
+		Excerpt:            Truncate(strings.Join(paragraphs, " "), EXCERPT_MAX_LENGTH),
+		WordCount:          words,
+		ReadingTimeMinutes: int(math.Ceil(float64(words) / WORDS_PER_MINUTE)),
+	}
+}
+
+// Truncate cuts value to at most maxLength characters at a word boundary,
+// adding an ellipsis when something was cut
+func Truncate(value string, maxLength int) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if utf8.RuneCountInString(value) <= maxLength {
+		return value
+	}
+
+	runes := []rune(value)[:maxLength]
+	cut := string(runes)
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:!?") + "…"
+}
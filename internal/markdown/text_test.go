@@ -0,0 +1,38 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Measure(t *testing.T) {
+	t.Run("Measure success", func(t *testing.T) {
+		value := Measure("## Hello new post!\n\nSome *text* with a [link](https://example.com).\n\n```go\nfmt.Println(\"hi\")\n```\n\n- one item\n")
+		assert.Equal(t, "Some text with a link. one item", value.Excerpt)
+		assert.Equal(t, 11, value.WordCount)
+		assert.Equal(t, 1, value.ReadingTimeMinutes)
+	})
+
+	t.Run("Measure without paragraphs", func(t *testing.T) {
+		value := Measure("## Hello new post!")
+		assert.Equal(t, "Hello new post!", value.Excerpt)
+		assert.Equal(t, 3, value.WordCount)
+
+		empty := Measure("")
+		assert.Equal(t, Stats{}, empty)
+	})
+
+	t.Run("Measure reading time", func(t *testing.T) {
+		value := Measure(strings.Repeat("word ", 401))
+		assert.Equal(t, 401, value.WordCount)
+		assert.Equal(t, 3, value.ReadingTimeMinutes)
+	})
+}
+
+func Test_Truncate(t *testing.T) {
+	assert.Equal(t, "short text", Truncate("  short\n text ", 20))
+	assert.Equal(t, "one two…", Truncate("one two, three", 10))
+	assert.Equal(t, "ăăă…", Truncate("ăăăăă", 3))
+}
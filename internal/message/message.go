@@ -14,15 +14,29 @@ const (
 // Failed message
 const (
 	INVALID_INPUT      = "Invalid input!"
+	VALIDATION_FAILED  = "Validation failed!"
+	INVALID_TAG        = "Invalid tag!"
+	INVALID_REFERENCE  = "Invalid reference!"
+	NOT_FOUND          = "Data not found!"
+	MOVED              = "Data moved!"
+	CONFLICT           = "Data already exists!"
+	ALREADY_PUBLISHED  = "Data already published!"
 	AUTH_FAILED        = "Authorize failed!"
 	LOGIN_FAILED       = "Login failed!"
 	GET_DATA_FAILED    = "Get data failed!"
 	CREATE_DATA_FAILED = "Create data failed!"
 	UPDATE_DATA_FAILED = "Update data failed!"
 	REMOVE_DATA_FAILED = "Remove data failed!"
+	REQUEST_CANCELED   = "Request canceled!"
+	REQUEST_TIMEOUT    = "Request timeout!"
 )
 
+// Response is the body of every response, Meta is only set on pages of
+// listings read with a page number and Cursors on pages of listings read
+// with cursors
 type Response struct {
 	Message string `json:"message"`
 	Data    any    `json:"data"`
+	Meta    any    `json:"meta,omitempty"`
+	Cursors any    `json:"cursors,omitempty"`
 }
@@ -0,0 +1,376 @@
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// VersionTable is the table goose keeps applied versions in, sharing it
+// lets databases migrated by goose be picked up where they are
+const VersionTable = "goose_db_version"
+
+// lockId is the advisory lock held while migrating, so that several
+// instances starting at once don't run the same migration twice
+const lockId = 5003_0001
+
+type Migration struct {
+	Version int64
+	Name    string
+
+	// Statements of each direction, run one by one
+	Up   []string
+	Down []string
+
+	// NoTransaction is set by "-- +goose NO TRANSACTION"
+	NoTransaction bool
+}
+
+type Status struct {
+	Migration Migration
+	AppliedAt *time.Time
+}
+
+// Parse reads a migration written for goose, it understands the
+// Up, Down, StatementBegin, StatementEnd and NO TRANSACTION annotations
+func Parse(name string, source string) (Migration, error) {
+	value := Migration{Name: name}
+
+	// Version is the number before the first underscore
+	versionString, _, found := strings.Cut(name, "_")
+	if !found {
+		return value, fmt.Errorf("%s: name must look like <version>_<name>.sql", name)
+	}
+	version, err := strconv.ParseInt(versionString, 10, 64)
+	if err != nil || version < 1 {
+		return value, fmt.Errorf("%s: invalid version %q", name, versionString)
+	}
+	value.Version = version
+
+	var statements *[]string
+	inBlock := false
+	buffer := strings.Builder{}
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	flush := func() {
+		statement := strings.TrimSpace(buffer.String())
+		buffer.Reset()
+		if statement != "" && statements != nil {
+			*statements = append(*statements, statement)
+		}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		// Handle goose annotations
+		if annotation, ok := strings.CutPrefix(trimmed, "-- +goose "); ok {
+			switch strings.TrimSpace(annotation) {
+			case "Up":
+				flush()
+				statements = &value.Up
+			case "Down":
+				flush()
+				statements = &value.Down
+			case "StatementBegin":
+				flush()
+				inBlock = true
+			case "StatementEnd":
+				if !inBlock {
+					return value, fmt.Errorf("%s: StatementEnd without StatementBegin", name)
+				}
+				inBlock = false
+				flush()
+			case "NO TRANSACTION":
+				value.NoTransaction = true
+			default:
+				return value, fmt.Errorf("%s: unsupported annotation %q", name, trimmed)
+			}
+			continue
+		}
+
+		// Outside of blocks skip comments and end statements at a semicolon
+		if !inBlock && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		if statements == nil {
+			return value, fmt.Errorf("%s: statement before -- +goose Up", name)
+		}
+		buffer.WriteString(line)
+		buffer.WriteString("\n")
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return value, err
+	}
+	if inBlock {
+		return value, fmt.Errorf("%s: StatementBegin without StatementEnd", name)
+	}
+	flush()
+
+	return value, nil
+}
+
+// Load parses every .sql file of fsys sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	value := []Migration{}
+	for _, name := range names {
+		source, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		migration, err := Parse(path.Base(name), string(source))
+		if err != nil {
+			return nil, err
+		}
+		value = append(value, migration)
+	}
+
+	sort.Slice(value, func(i, j int) bool { return value[i].Version < value[j].Version })
+	for i := 1; i < len(value); i++ {
+		if value[i].Version == value[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", value[i].Version)
+		}
+	}
+
+	return value, nil
+}
+
+type Migrator struct {
+	Pool       *pgxpool.Pool
+	Migrations []Migration
+}
+
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{Pool: pool, Migrations: migrations}, nil
+}
+
+// Up applies every pending migration, it returns the applied ones
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	value := []Migration{}
+	err := m.locked(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.Migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			value = append(value, migration)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// Down rolls back the latest applied migration, nil if there is none
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var value *Migration
+	err := m.locked(ctx, func(conn *pgx.Conn) error {
+		migration, err := m.latest(ctx, conn)
+		if err != nil || migration == nil {
+			return err
+		}
+		if err := run(ctx, conn, *migration, false); err != nil {
+			return err
+		}
+		value = migration
+		return nil
+	})
+	return value, err
+}
+
+// Redo rolls back and applies again the latest applied migration
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var value *Migration
+	err := m.locked(ctx, func(conn *pgx.Conn) error {
+		migration, err := m.latest(ctx, conn)
+		if err != nil || migration == nil {
+			return err
+		}
+		if err := run(ctx, conn, *migration, false); err != nil {
+			return err
+		}
+		if err := run(ctx, conn, *migration, true); err != nil {
+			return err
+		}
+		value = migration
+		return nil
+	})
+	return value, err
+}
+
+// Status lists every migration with the time it was applied at
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	value := []Status{}
+	err := m.locked(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.Migrations {
+			status := Status{Migration: migration}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			value = append(value, status)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// latest returns the known migration applied last, nil if there is none
+func (m *Migrator) latest(ctx context.Context, conn *pgx.Conn) (*Migration, error) {
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(m.Migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.Migrations[i].Version]; ok {
+			migration := m.Migrations[i]
+			return &migration, nil
+		}
+	}
+	return nil, nil
+}
+
+// locked runs fn on one connection holding the migration advisory lock,
+// after making sure the version table exists
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := m.Pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1);", lockId); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1);", lockId)
+	}()
+
+	if err := ensureVersionTable(ctx, conn.Conn()); err != nil {
+		return err
+	}
+	return fn(conn.Conn())
+}
+
+// ensureVersionTable creates the version table the way goose does,
+// including the row for version 0
+func ensureVersionTable(ctx context.Context, conn *pgx.Conn) error {
+	exists := false
+	err := conn.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL;", VersionTable).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		createSql := `
+			CREATE TABLE ` + VersionTable + ` (
+				id INTEGER PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+				version_id BIGINT NOT NULL,
+				is_applied BOOLEAN NOT NULL,
+				tstamp TIMESTAMP NOT NULL DEFAULT NOW()
+			);
+		`
+		if _, err := tx.Exec(ctx, createSql); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "INSERT INTO "+VersionTable+" (version_id, is_applied) VALUES (0, TRUE);")
+		return err
+	})
+}
+
+// appliedVersions returns the applied versions with the time they were
+// applied at. The latest row of a version decides, older goose releases
+// recorded rollbacks as rows with is_applied false.
+func appliedVersions(ctx context.Context, conn *pgx.Conn) (map[int64]time.Time, error) {
+	sql := `
+		SELECT DISTINCT ON (version_id) version_id, is_applied, tstamp
+		FROM ` + VersionTable + `
+		WHERE version_id > 0
+		ORDER BY version_id, id DESC;
+	`
+	rows, err := conn.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	value := map[int64]time.Time{}
+	for rows.Next() {
+		version := int64(0)
+		isApplied := false
+		appliedAt := time.Time{}
+		if err := rows.Scan(&version, &isApplied, &appliedAt); err != nil {
+			return nil, err
+		}
+		if isApplied {
+			value[version] = appliedAt
+		}
+	}
+
+	return value, rows.Err()
+}
+
+// run applies or rolls back one migration and records it in the version table
+func run(ctx context.Context, conn *pgx.Conn, migration Migration, up bool) error {
+	statements := migration.Down
+	recordSql := "DELETE FROM " + VersionTable + " WHERE version_id = $1;"
+	if up {
+		statements = migration.Up
+		recordSql = "INSERT INTO " + VersionTable + " (version_id, is_applied) VALUES ($1, TRUE);"
+	}
+
+	apply := func(tx pgx.Tx) error {
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return fmt.Errorf("%s: %w", migration.Name, err)
+			}
+		}
+		_, err := tx.Exec(ctx, recordSql, migration.Version)
+		return err
+	}
+	if !migration.NoTransaction {
+		return pgx.BeginFunc(ctx, conn, apply)
+	}
+
+	for _, statement := range statements {
+		if _, err := conn.Exec(ctx, statement); err != nil {
+			return fmt.Errorf("%s: %w", migration.Name, err)
+		}
+	}
+	_, err := conn.Exec(ctx, recordSql, migration.Version)
+	return err
+}
@@ -0,0 +1,99 @@
+package migrate
+
+import (
+	"api-chi/migrations"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	t.Run("Parse success", func(t *testing.T) {
+		source := `-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE a (id INT);
+CREATE TABLE b (id INT);
+-- +goose StatementEnd
+
+-- A comment
+CREATE INDEX a_id ON a
+    (id);
+
+-- +goose Down
+DROP TABLE b;
+DROP TABLE a;
+`
+		value, err := Parse("20250101000000_tables.sql", source)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(20250101000000), value.Version)
+		assert.Equal(t, "20250101000000_tables.sql", value.Name)
+		assert.Equal(t, []string{
+			"CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);",
+			"CREATE INDEX a_id ON a\n    (id);",
+		}, value.Up)
+		assert.Equal(t, []string{"DROP TABLE b;", "DROP TABLE a;"}, value.Down)
+		assert.False(t, value.NoTransaction)
+	})
+
+	t.Run("Parse no transaction success", func(t *testing.T) {
+		source := "-- +goose NO TRANSACTION\n-- +goose Up\nCREATE INDEX CONCURRENTLY a_id ON a (id);\n"
+		value, err := Parse("2_index.sql", source)
+		assert.Nil(t, err)
+		assert.True(t, value.NoTransaction)
+		assert.Len(t, value.Up, 1)
+		assert.Empty(t, value.Down)
+	})
+
+	t.Run("Parse failed", func(t *testing.T) {
+		sources := map[string]string{
+			"1_no_direction.sql": "CREATE TABLE a (id INT);\n",
+			"1_open_block.sql":   "-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n",
+			"1_close_block.sql":  "-- +goose Up\n-- +goose StatementEnd\n",
+			"1_annotation.sql":   "-- +goose Sideways\n",
+			"no_version.sql":     "-- +goose Up\n",
+			"0_zero_version.sql": "-- +goose Up\n",
+			"missing-underscore": "-- +goose Up\n",
+		}
+		for name, source := range sources {
+			_, err := Parse(name, source)
+			assert.NotNil(t, err, name)
+		}
+	})
+}
+
+func Test_Load(t *testing.T) {
+	t.Run("Load sorted success", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"3_c.sql":   {Data: []byte("-- +goose Up\nSELECT 3;\n")},
+			"1_a.sql":   {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+			"2_b.sql":   {Data: []byte("-- +goose Up\nSELECT 2;\n")},
+			"README.md": {Data: []byte("not a migration")},
+		}
+		value, err := Load(fsys)
+		assert.Nil(t, err)
+		assert.Len(t, value, 3)
+		for i, migration := range value {
+			assert.Equal(t, int64(i+1), migration.Version)
+		}
+	})
+
+	t.Run("Load failed with duplicate version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"1_a.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+			"1_b.sql": {Data: []byte("-- +goose Up\nSELECT 2;\n")},
+		}
+		_, err := Load(fsys)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Load embedded migrations success", func(t *testing.T) {
+		value, err := Load(migrations.FS)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, value)
+		for _, migration := range value {
+			assert.NotEmpty(t, migration.Up, migration.Name)
+			assert.NotEmpty(t, migration.Down, migration.Name)
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package validate
+
+import (
+	"api-chi/cmd/models"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Length limits of the fields
+const (
+	TITLE_MAX_LENGTH    = 255
+	SLUG_MAX_LENGTH     = 255
+	SUMMARY_MAX_LENGTH  = 500
+	CONTENT_MAX_LENGTH  = 100_000
+	TAG_NAME_MAX_LENGTH = 50
+	POST_MAX_TAGS       = 20
+
+	SERIES_DESCRIPTION_MAX_LENGTH = 1000
+	SERIES_MAX_PARTS              = 100
+)
+
+// Rules a field can fail
+const (
+	RULE_REQUIRED   = "required"
+	RULE_MAX_LENGTH = "max_length"
+	RULE_UUID       = "uuid"
+	RULE_NOT_ZERO   = "not_zero"
+	RULE_SLUG       = "slug"
+	RULE_RESERVED   = "reserved"
+	RULE_MIN        = "min"
+	RULE_UNIQUE     = "unique"
+)
+
+// RESERVED_SLUGS can't be taken by posts, they are route names or may
+// become one. Generated slugs falling on them get a number like collisions.
+var RESERVED_SLUGS = []string{
+	"admin", "api", "archive", "content", "count", "draft", "drafts", "edit",
+	"feed", "new", "preview", "related", "rss", "search", "series", "tags",
+}
+
+// IsReservedSlug tells whether slug is one of RESERVED_SLUGS
+func IsReservedSlug(slug string) bool {
+	return slices.Contains(RESERVED_SLUGS, slug)
+}
+
+var slugRegexp = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// FieldError is one rule a field of the input failed
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// Errors lists every failing field of an input, it is nil when the input is valid
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fieldError := range e {
+		parts[i] = fmt.Sprintf("%s: %s", fieldError.Field, fieldError.Rule)
+	}
+	return "invalid input: " + strings.Join(parts, ", ")
+}
+
+// result returns e as an error, nil if there is no failing field
+func (e Errors) result() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *Errors) required(field string, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		*e = append(*e, FieldError{Field: field, Rule: RULE_REQUIRED})
+		return false
+	}
+	return true
+}
+
+func (e *Errors) maxLength(field string, value string, limit int) {
+	if utf8.RuneCountInString(value) > limit {
+		*e = append(*e, FieldError{Field: field, Rule: RULE_MAX_LENGTH, Limit: limit})
+	}
+}
+
+func (e *Errors) uuid(field string, value string) {
+	if !e.required(field, value) {
+		return
+	}
+	if !uuidRegexp.MatchString(value) {
+		*e = append(*e, FieldError{Field: field, Rule: RULE_UUID})
+	}
+}
+
+// slug checks an optional slug chosen by the author
+func (e *Errors) slug(field string, value string) {
+	if value == "" {
+		return
+	}
+	switch {
+	case utf8.RuneCountInString(value) > SLUG_MAX_LENGTH:
+		*e = append(*e, FieldError{Field: field, Rule: RULE_MAX_LENGTH, Limit: SLUG_MAX_LENGTH})
+	case !slugRegexp.MatchString(value):
+		*e = append(*e, FieldError{Field: field, Rule: RULE_SLUG})
+	case IsReservedSlug(value):
+		*e = append(*e, FieldError{Field: field, Rule: RULE_RESERVED})
+	}
+}
+
+func (e *Errors) notZero(field string, value time.Time) {
+	if value.IsZero() {
+		*e = append(*e, FieldError{Field: field, Rule: RULE_NOT_ZERO})
+	}
+}
+
+// post checks the fields shared by created and updated posts
+func (e *Errors) post(title string, slug string, summary string, content string, createdAt time.Time, updatedAt time.Time, tags []models.BlogTag) {
+	if e.required("title", title) {
+		e.maxLength("title", title, TITLE_MAX_LENGTH)
+	}
+	e.slug("slug", slug)
+	e.maxLength("summary", summary, SUMMARY_MAX_LENGTH)
+	e.maxLength("content", content, CONTENT_MAX_LENGTH)
+	e.notZero("created_at", createdAt)
+	e.notZero("updated_at", updatedAt)
+
+	if len(tags) > POST_MAX_TAGS {
+		*e = append(*e, FieldError{Field: "tags", Rule: RULE_MAX_LENGTH, Limit: POST_MAX_TAGS})
+	}
+	tagIds := map[string]bool{}
+	for i, tag := range tags {
+		e.uuid(fmt.Sprintf("tags[%d].id", i), tag.Id)
+		if tagIds[strings.ToLower(tag.Id)] {
+			*e = append(*e, FieldError{Field: fmt.Sprintf("tags[%d].id", i), Rule: RULE_UNIQUE})
+		}
+		tagIds[strings.ToLower(tag.Id)] = true
+	}
+}
+
+func BlogPostCreated(input *models.BlogPostCreated) error {
+	e := Errors{}
+	e.post(input.Title, input.Slug, input.Summary, input.Content, input.CreatedAt, input.UpdatedAt, input.Tags)
+	return e.result()
+}
+
+func BlogPostUpdated(input *models.BlogPostUpdated) error {
+	e := Errors{}
+	e.uuid("id", input.Id)
+	e.post(input.Title, input.Slug, input.Summary, input.Content, input.CreatedAt, input.UpdatedAt, input.Tags)
+	return e.result()
+}
+
+func BlogTagCreated(input *models.BlogTag) error {
+	e := Errors{}
+	if e.required("name", input.Name) {
+		e.maxLength("name", input.Name, TAG_NAME_MAX_LENGTH)
+	}
+	return e.result()
+}
+
+func BlogTagUpdated(input *models.BlogTag) error {
+	e := Errors{}
+	e.uuid("id", input.Id)
+	if e.required("name", input.Name) {
+		e.maxLength("name", input.Name, TAG_NAME_MAX_LENGTH)
+	}
+	return e.result()
+}
+
+// series checks the fields shared by created and updated series
+func (e *Errors) series(title string, slug string, description string, parts []models.BlogSeriesPart) {
+	if e.required("title", title) {
+		e.maxLength("title", title, TITLE_MAX_LENGTH)
+	}
+	e.slug("slug", slug)
+	e.maxLength("description", description, SERIES_DESCRIPTION_MAX_LENGTH)
+
+	if len(parts) > SERIES_MAX_PARTS {
+		*e = append(*e, FieldError{Field: "parts", Rule: RULE_MAX_LENGTH, Limit: SERIES_MAX_PARTS})
+	}
+	postIds := map[string]bool{}
+	positions := map[int]bool{}
+	for i, part := range parts {
+		e.uuid(fmt.Sprintf("parts[%d].post_id", i), part.PostId)
+		if postIds[strings.ToLower(part.PostId)] {
+			*e = append(*e, FieldError{Field: fmt.Sprintf("parts[%d].post_id", i), Rule: RULE_UNIQUE})
+		}
+		postIds[strings.ToLower(part.PostId)] = true
+
+		if part.Position < 1 {
+			*e = append(*e, FieldError{Field: fmt.Sprintf("parts[%d].position", i), Rule: RULE_MIN, Limit: 1})
+		} else if positions[part.Position] {
+			*e = append(*e, FieldError{Field: fmt.Sprintf("parts[%d].position", i), Rule: RULE_UNIQUE})
+		}
+		positions[part.Position] = true
+	}
+}
+
+func BlogSeriesCreated(input *models.BlogSeriesCreated) error {
+	e := Errors{}
+	e.series(input.Title, input.Slug, input.Description, input.Parts)
+	return e.result()
+}
+
+func BlogSeriesUpdated(input *models.BlogSeriesUpdated) error {
+	e := Errors{}
+	e.uuid("id", input.Id)
+	e.series(input.Title, input.Slug, input.Description, input.Parts)
+	return e.result()
+}
@@ -0,0 +1,150 @@
+package validate
+
+import (
+	"api-chi/cmd/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BlogPost(t *testing.T) {
+	now := time.Now()
+	tagId := "0b5c6f0e-4d0e-4b8e-9a43-3c1f0f7a2d11"
+
+	t.Run("BlogPostCreated success", func(t *testing.T) {
+		input := models.BlogPostCreated{
+			Title:     "Hello",
+			Content:   "## Hello!",
+			CreatedAt: now,
+			UpdatedAt: now,
+			Tags:      []models.BlogTag{{Id: tagId}},
+		}
+		assert.NoError(t, BlogPostCreated(&input))
+	})
+
+	t.Run("BlogPostCreated failed", func(t *testing.T) {
+		input := models.BlogPostCreated{
+			Title:     strings.Repeat("a", TITLE_MAX_LENGTH+1),
+			Summary:   strings.Repeat("a", SUMMARY_MAX_LENGTH+1),
+			CreatedAt: now,
+			Tags:      []models.BlogTag{{Id: tagId}, {Id: "abc"}, {Id: strings.ToUpper(tagId)}},
+		}
+		err := BlogPostCreated(&input)
+		assert.Equal(t, Errors{
+			{Field: "title", Rule: RULE_MAX_LENGTH, Limit: TITLE_MAX_LENGTH},
+			{Field: "summary", Rule: RULE_MAX_LENGTH, Limit: SUMMARY_MAX_LENGTH},
+			{Field: "updated_at", Rule: RULE_NOT_ZERO},
+			{Field: "tags[1].id", Rule: RULE_UUID},
+			{Field: "tags[2].id", Rule: RULE_UNIQUE},
+		}, err)
+	})
+
+	t.Run("BlogPostUpdated failed", func(t *testing.T) {
+		input := models.BlogPostUpdated{
+			Title:     "Hello",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		err := BlogPostUpdated(&input)
+		assert.Equal(t, Errors{{Field: "id", Rule: RULE_REQUIRED}}, err)
+
+		input.Id = "not-a-uuid"
+		err = BlogPostUpdated(&input)
+		assert.Equal(t, Errors{{Field: "id", Rule: RULE_UUID}}, err)
+
+		input.Id = tagId
+		assert.NoError(t, BlogPostUpdated(&input))
+	})
+
+	t.Run("Slug", func(t *testing.T) {
+		tests := []struct {
+			slug string
+			rule string
+		}{
+			{"", ""},
+			{"my-post-2", ""},
+			{"My-Post", RULE_SLUG},
+			{"my--post", RULE_SLUG},
+			{"-my-post", RULE_SLUG},
+			{"my_post", RULE_SLUG},
+			{"archive", RULE_RESERVED},
+			{strings.Repeat("a", SLUG_MAX_LENGTH+1), RULE_MAX_LENGTH},
+		}
+		for _, test := range tests {
+			input := models.BlogPostCreated{Title: "Hello", Slug: test.slug, CreatedAt: now, UpdatedAt: now}
+			err := BlogPostCreated(&input)
+			if test.rule == "" {
+				assert.NoError(t, err, test.slug)
+				continue
+			}
+			validationErrors := Errors{}
+			assert.ErrorAs(t, err, &validationErrors, test.slug)
+			assert.Equal(t, "slug", validationErrors[0].Field, test.slug)
+			assert.Equal(t, test.rule, validationErrors[0].Rule, test.slug)
+		}
+	})
+
+	t.Run("Title length counts characters", func(t *testing.T) {
+		input := models.BlogPostCreated{
+			Title:     strings.Repeat("ă", TITLE_MAX_LENGTH),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		assert.NoError(t, BlogPostCreated(&input))
+	})
+}
+
+func Test_BlogTag(t *testing.T) {
+	t.Run("BlogTagCreated", func(t *testing.T) {
+		assert.NoError(t, BlogTagCreated(&models.BlogTag{Name: "go"}))
+		assert.Equal(t, Errors{{Field: "name", Rule: RULE_REQUIRED}}, BlogTagCreated(&models.BlogTag{}))
+		assert.Equal(t,
+			Errors{{Field: "name", Rule: RULE_MAX_LENGTH, Limit: TAG_NAME_MAX_LENGTH}},
+			BlogTagCreated(&models.BlogTag{Name: strings.Repeat("a", TAG_NAME_MAX_LENGTH+1)}),
+		)
+	})
+
+	t.Run("BlogTagUpdated", func(t *testing.T) {
+		assert.NoError(t, BlogTagUpdated(&models.BlogTag{Id: "0b5c6f0e-4d0e-4b8e-9a43-3c1f0f7a2d11", Name: "go"}))
+		assert.Equal(t, Errors{{Field: "id", Rule: RULE_UUID}}, BlogTagUpdated(&models.BlogTag{Id: "1", Name: "go"}))
+	})
+}
+
+func Test_BlogSeries(t *testing.T) {
+	postId := "0b5c6f0e-4d0e-4b8e-9a43-3c1f0f7a2d11"
+	otherId := "6f1a2b3c-4d5e-4f60-8a7b-9c0d1e2f3a4b"
+
+	t.Run("BlogSeriesCreated success", func(t *testing.T) {
+		input := models.BlogSeriesCreated{
+			Title: "Go tutorial",
+			Parts: []models.BlogSeriesPart{{PostId: postId, Position: 2}, {PostId: otherId, Position: 5}},
+		}
+		assert.NoError(t, BlogSeriesCreated(&input))
+	})
+
+	t.Run("BlogSeriesCreated failed", func(t *testing.T) {
+		input := models.BlogSeriesCreated{
+			Description: strings.Repeat("a", SERIES_DESCRIPTION_MAX_LENGTH+1),
+			Parts: []models.BlogSeriesPart{
+				{PostId: postId, Position: 1},
+				{PostId: postId, Position: 1},
+				{PostId: "abc", Position: 0},
+			},
+		}
+		assert.Equal(t, Errors{
+			{Field: "title", Rule: RULE_REQUIRED},
+			{Field: "description", Rule: RULE_MAX_LENGTH, Limit: SERIES_DESCRIPTION_MAX_LENGTH},
+			{Field: "parts[1].post_id", Rule: RULE_UNIQUE},
+			{Field: "parts[1].position", Rule: RULE_UNIQUE},
+			{Field: "parts[2].post_id", Rule: RULE_UUID},
+			{Field: "parts[2].position", Rule: RULE_MIN, Limit: 1},
+		}, BlogSeriesCreated(&input))
+	})
+
+	t.Run("BlogSeriesUpdated", func(t *testing.T) {
+		assert.NoError(t, BlogSeriesUpdated(&models.BlogSeriesUpdated{Id: postId, Title: "Go tutorial"}))
+		assert.Equal(t, Errors{{Field: "id", Rule: RULE_UUID}}, BlogSeriesUpdated(&models.BlogSeriesUpdated{Id: "1", Title: "Go tutorial"}))
+	})
+}
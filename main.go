@@ -3,9 +3,14 @@ package main
 import (
 	"api-chi/cmd/config"
 	"api-chi/cmd/routes"
+	"api-chi/cmd/services"
+	"api-chi/internal/migrate"
+	"api-chi/migrations"
+	"context"
+	"fmt"
 	"log"
 	"net/http"
-	"fmt"
+	"os"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -13,10 +18,31 @@ import (
 )
 
 func main() {
-	// Load configurations for the API and Web
-	config.LoadAuthConfig()
-	config.LoadApiConfig()
-	config.LoadWebConfig()
+	// Config file is optional, the environment alone is enough
+	configFile := os.Getenv(config.FILE_ENV)
+
+	// Run subcommands instead of the server
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "migrate":
+			err = runMigrate(context.Background(), os.Stdout, configFile, os.Args[2:])
+		case "config":
+			err = runConfig(os.Stdout, configFile, os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command %q, expected migrate or config", os.Args[1])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Load configurations for the API and Web, stop at the first invalid one
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		log.Fatalf("invalid config:\n%v", err)
+	}
 
 	// Create a new router
 	r := chi.NewRouter()
@@ -26,24 +52,88 @@ func main() {
 
 	// CORS settings - restrict only to the allowed origins
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{config.WEB_URL},                                            // Restrict to local dev
+		AllowedOrigins:   []string{cfg.Web.Url},                                               // Restrict to local dev
 		AllowedMethods:   []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},               // Allow necessary HTTP methods
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"}, // Explicitly allow headers
 		ExposedHeaders:   []string{"Link"},                                                    // Headers that can be exposed to the frontend
 		AllowCredentials: true,                                                                // Allow cookies and other credentials
 	}))
 
+	// Create the services once, Postgres unless memory is asked for
+	deps := routes.Dependencies{
+		Auth:         services.NewAuthService(cfg.Auth),
+		ReadTimeout:  cfg.Api.ReadTimeout,
+		WriteTimeout: cfg.Api.WriteTimeout,
+	}
+	if cfg.Api.Store == config.STORE_MEMORY {
+		store := services.NewMemoryStore()
+		deps.Posts = services.NewMemoryBlogPostService(store)
+		deps.Tags = services.NewMemoryBlogTagService(store)
+		deps.Revisions = services.NewMemoryBlogPostRevisionService(store)
+		deps.Series = services.NewMemoryBlogSeriesService(store)
+	} else {
+		database, err := services.NewDatabaseService(context.Background(), cfg.Postgres)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer database.Close()
+
+		// Apply pending migrations before serving if asked for
+		if cfg.Api.AutoMigrate {
+			migrator, err := migrate.New(database.Pool, migrations.FS)
+			if err != nil {
+				log.Fatal(err)
+			}
+			applied, err := migrator.Up(context.Background())
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, migration := range applied {
+				fmt.Println("Applied migration", migration.Name)
+			}
+		}
+
+		// Posts can't be indexed nor searched with an unknown language
+		if err := database.CheckSearchLanguage(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+
+		// Index posts again when the search language changed. A failure only
+		// leaves search stale, like before migrations or on a read only
+		// replica, so the API is served anyway.
+		posts := services.NewBlogPostService(database)
+		reindexed, err := posts.ReindexSearch(context.Background())
+		if err != nil {
+			log.Println("reindex posts for search:", err)
+		} else if reindexed > 0 {
+			fmt.Println("Indexed", reindexed, "posts for search in", database.SearchLanguage)
+		}
+
+		deps.Posts = posts
+		deps.Tags = services.NewBlogTagService(database)
+		deps.Revisions = services.NewBlogPostRevisionService(database)
+		deps.Series = services.NewBlogSeriesService(database)
+	}
+
+	// Publish scheduled posts in the background
+	if cfg.Api.PublishInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go services.NewPublisher(deps.Posts, cfg.Api.PublishInterval).Run(ctx)
+	}
+
 	// Define the /api route and its subroutes
 	r.Route("/api", func(r chi.Router) {
-		routes.AuthRoutes(r)
-		routes.BlogPostRoutes(r)
-		routes.BlogTagRoutes(r)
+		routes.AuthRoutes(r, deps)
+		routes.BlogPostRoutes(r, deps)
+		routes.BlogTagRoutes(r, deps)
+		routes.BlogSeriesRoutes(r, deps)
 	})
 
-	fmt.Println("Starting API server on port", config.API_PORT)
+	fmt.Println("Starting API server on port", cfg.Api.Port)
 
 	// Start the HTTP server on the specified API port
-	err := http.ListenAndServe(":"+config.API_PORT, r)
+	err = http.ListenAndServe(":"+cfg.Api.Port, r)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"api-chi/cmd/config"
+	"api-chi/cmd/services"
+	"api-chi/internal/migrate"
+	"api-chi/migrations"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const migrateUsage = "usage: api-chi migrate up|down|status|redo"
+
+// runMigrate runs the "api-chi migrate" subcommand, only the postgres
+// section of the config is needed
+func runMigrate(ctx context.Context, w io.Writer, configFile string, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	cfg, err := config.Read(configFile)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Postgres.Validate(); err != nil {
+		return err
+	}
+
+	database, err := services.NewDatabaseService(ctx, cfg.Postgres)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	migrator, err := migrate.New(database.Pool, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintln(w, "OK", migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(w, "No migration to apply")
+		}
+		return err
+
+	case "down", "redo":
+		run := migrator.Down
+		if args[0] == "redo" {
+			run = migrator.Redo
+		}
+		migration, err := run(ctx)
+		if err != nil {
+			return err
+		}
+		if migration == nil {
+			fmt.Fprintln(w, "No migration to roll back")
+			return nil
+		}
+		fmt.Fprintln(w, "OK", migration.Name)
+		return nil
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%-30s %s\n", "Applied At", "Migration")
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%-30s %s\n", appliedAt, status.Migration.Name)
+		}
+		return nil
+	}
+
+	return errors.New(migrateUsage)
+}
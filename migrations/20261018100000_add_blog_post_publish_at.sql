@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.blog_post ADD COLUMN publish_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX blog_post_scheduled_idx ON public.blog_post (publish_at) WHERE is_draft AND publish_at IS NOT NULL;
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS blog_post_scheduled_idx;
+
+ALTER TABLE public.blog_post DROP COLUMN IF EXISTS publish_at;
+
+-- +goose StatementEnd
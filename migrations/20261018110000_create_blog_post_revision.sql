@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.blog_post_revision (
+    id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID (),
+    post_id UUID NOT NULL,
+    number INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_post_for_blog_post_revision FOREIGN KEY (post_id) REFERENCES public.blog_post (id) ON DELETE CASCADE,
+    CONSTRAINT blog_post_revision_number_key UNIQUE (post_id, number)
+);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS blog_post_revision;
+
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.blog_post_slug (
+    slug TEXT PRIMARY KEY,
+    post_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_post_for_blog_post_slug FOREIGN KEY (post_id) REFERENCES public.blog_post (id) ON DELETE CASCADE
+);
+
+CREATE INDEX blog_post_slug_post_id_idx ON public.blog_post_slug (post_id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS blog_post_slug;
+
+-- +goose StatementEnd
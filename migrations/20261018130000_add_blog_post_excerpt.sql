@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.blog_post
+    ADD COLUMN summary TEXT NOT NULL DEFAULT '',
+    ADD COLUMN excerpt TEXT NOT NULL DEFAULT '',
+    ADD COLUMN word_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN reading_time_minutes INTEGER NOT NULL DEFAULT 0;
+
+-- Existing posts get rough stats from their raw content, they are made
+-- exact the next time the post is saved
+UPDATE public.blog_post SET
+    word_count = COALESCE(array_length(regexp_split_to_array(btrim(content), '\s+'), 1), 0),
+    excerpt = left(btrim(regexp_replace(regexp_replace(content, '[#*_`>\[\]]', '', 'g'), '\s+', ' ', 'g')), 200)
+WHERE btrim(content) <> '';
+
+UPDATE public.blog_post SET reading_time_minutes = CEIL(word_count / 200.0);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.blog_post
+    DROP COLUMN IF EXISTS summary,
+    DROP COLUMN IF EXISTS excerpt,
+    DROP COLUMN IF EXISTS word_count,
+    DROP COLUMN IF EXISTS reading_time_minutes;
+
+-- +goose StatementEnd
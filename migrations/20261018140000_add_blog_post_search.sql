@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.blog_post
+    ADD COLUMN search_vector TSVECTOR NOT NULL DEFAULT ''::tsvector,
+    ADD COLUMN search_language TEXT NOT NULL DEFAULT 'english';
+
+-- Index existing posts in english, the API indexes them again on start
+-- when another language is configured
+UPDATE public.blog_post SET search_vector =
+    setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', content), 'B');
+
+CREATE INDEX blog_post_search_idx ON public.blog_post USING GIN (search_vector);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS blog_post_search_idx;
+
+ALTER TABLE public.blog_post
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS search_language;
+
+-- +goose StatementEnd
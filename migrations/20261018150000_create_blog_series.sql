@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.blog_series (
+    id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID (),
+    title TEXT NOT NULL,
+    slug TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A post is a part of one series at most, at a position of its own
+CREATE TABLE public.blog_series_post (
+    series_id UUID NOT NULL,
+    post_id UUID NOT NULL UNIQUE,
+    position INTEGER NOT NULL CHECK (position > 0),
+    PRIMARY KEY (series_id, position),
+    CONSTRAINT fk_series_for_blog_series_post FOREIGN KEY (series_id) REFERENCES public.blog_series (id) ON DELETE CASCADE,
+    CONSTRAINT fk_post_for_blog_series_post FOREIGN KEY (post_id) REFERENCES public.blog_post (id) ON DELETE CASCADE
+);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS blog_series_post;
+
+DROP TABLE IF EXISTS blog_series;
+
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A tag is linked to a post once, duplicated links are dropped first
+DELETE FROM public.blog_post_tag AS duplicate
+USING public.blog_post_tag AS kept
+WHERE duplicate.post_id = kept.post_id
+    AND duplicate.tag_id = kept.tag_id
+    AND duplicate.id > kept.id;
+
+DELETE FROM public.blog_post_tag WHERE post_id IS NULL OR tag_id IS NULL;
+
+ALTER TABLE public.blog_post_tag DROP COLUMN id;
+
+ALTER TABLE public.blog_post_tag ADD PRIMARY KEY (post_id, tag_id);
+
+CREATE INDEX blog_post_tag_tag_id_idx ON public.blog_post_tag (tag_id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS blog_post_tag_tag_id_idx;
+
+ALTER TABLE public.blog_post_tag DROP CONSTRAINT blog_post_tag_pkey;
+
+ALTER TABLE public.blog_post_tag ALTER COLUMN post_id DROP NOT NULL, ALTER COLUMN tag_id DROP NOT NULL;
+
+ALTER TABLE public.blog_post_tag ADD COLUMN id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID ();
+
+-- +goose StatementEnd
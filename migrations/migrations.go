@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS holds the goose annotated migrations built into the binary
+//
+//go:embed *.sql
+var FS embed.FS
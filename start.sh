@@ -6,7 +6,7 @@ set -e
 
 # Run migration
 echo "Running migration"
-goose up
+./api-chi migrate up
 
 # Start server
 echo "Starting server..."